@@ -2,12 +2,16 @@ package ast
 
 import (
 	"bytes"
+	"fmt"
 	"skibidilang/token"
+	"strings"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the first character belonging to the node
+	End() token.Position // position immediately after the node
 }
 
 type Statement interface {
@@ -32,8 +36,10 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+// LetStatement binds Name to Value. A binding introduced with
+// token.CONST (sigma) cannot be assigned to afterwards.
 type LetStatement struct {
-	Token token.Token // the token.LET token
+	Token token.Token // the token.LET or token.CONST token
 	Name  *Identifier
 	Value Expression
 }
@@ -75,6 +81,153 @@ type InfixExpression struct {
 	Right    Expression
 }
 
+type BlockStatement struct {
+	Token      token.Token // the { token
+	Statements []Statement
+	Rbrace     token.Token // the closing } token
+}
+
+type IfExpression struct {
+	Token       token.Token // the 'if' token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative Node // *BlockStatement, *IfExpression for an else-if chain, or nil
+}
+
+type FunctionLiteral struct {
+	Token      token.Token // the 'ohio' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+type CallExpression struct {
+	Token     token.Token // the ( token
+	Function  Expression  // Identifier or any expression evaluating to a function
+	Arguments []Expression
+	Rparen    token.Token // the closing ) token
+}
+
+type ArrayLiteral struct {
+	Token    token.Token // the [ token
+	Elements []Expression
+	Rbrack   token.Token // the closing ] token
+}
+
+type IndexExpression struct {
+	Token  token.Token // the [ token
+	Left   Expression
+	Index  Expression
+	Rbrack token.Token // the closing ] token
+}
+
+// SliceExpression is left[low:high], either bound may be nil
+type SliceExpression struct {
+	Token  token.Token // the [ token
+	Left   Expression
+	Low    Expression
+	High   Expression
+	Rbrack token.Token // the closing ] token
+}
+
+type HashLiteral struct {
+	Token  token.Token // the { token
+	Pairs  []HashPair  // in source order
+	Rbrace token.Token // the closing } token
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// PostfixExpression is an increment or decrement written after its operand
+type PostfixExpression struct {
+	Token    token.Token // the ++ or -- token
+	Left     Expression
+	Operator string
+}
+
+type StringLiteral struct {
+	Token token.Token // the token.STRING token, its literal holds the decoded value
+	Value string
+}
+
+// InterpolatedString is a string literal with embedded expressions. The
+// literal text and the expressions alternate, starting and ending with
+// text: Literals[0] Expressions[0] Literals[1] ... Literals[n].
+type InterpolatedString struct {
+	Token       token.Token // the token.TEMPLATE_HEAD token
+	Literals    []*StringLiteral
+	Expressions []Expression
+}
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+// LogicalExpression is a short-circuiting && or ||: Right is only
+// evaluated when Left does not already decide the result
+type LogicalExpression struct {
+	Token    token.Token // the && or || token
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+// AssignExpression is a plain (=) or compound (+=, -=, ...) assignment
+type AssignExpression struct {
+	Token    token.Token // the assignment operator token
+	Target   Expression  // Identifier or IndexExpression
+	Operator string
+	Value    Expression
+}
+
+// WhileStatement repeats Body as long as Condition is truthy
+type WhileStatement struct {
+	Token     token.Token // the 'grind' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+// ForStatement is a C-style loop, Init, Condition and Post may be nil
+type ForStatement struct {
+	Token     token.Token // the 'rizz' token
+	Init      Statement
+	Condition Expression
+	Post      Expression
+	Body      *BlockStatement
+}
+
+// ForInStatement runs Body once for every element of Iterable, bound to
+// Variable
+type ForInStatement struct {
+	Token    token.Token // the 'rizz' token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+type BreakStatement struct {
+	Token token.Token // the 'yeet' token
+}
+
+type ContinueStatement struct {
+	Token token.Token // the 'mew' token
+}
+
+// BadExpression is a placeholder for an expression containing syntax errors
+type BadExpression struct {
+	Token token.Token    // the token at which the error was detected
+	To    token.Position // position immediately after the last consumed token
+}
+
+// BadStatement is a placeholder for a statement containing syntax errors
+type BadStatement struct {
+	Token token.Token    // the first token of the statement
+	To    token.Position // position immediately after the last skipped token
+}
+
 // Important useless dummy methods that make  structs implement interfaces
 func (b *Boolean) expressionNode()                   {}
 func (b *Boolean) TokenLiteral() string              { return b.Token.Literal }
@@ -94,6 +247,216 @@ func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
 func (pe *PrefixExpression) expressionNode()         {}
 func (pe *PrefixExpression) TokenLiteral() string    { return pe.Token.Literal }
+func (bs *BlockStatement) statementNode()            {}
+func (bs *BlockStatement) TokenLiteral() string      { return bs.Token.Literal }
+func (ie *IfExpression) expressionNode()             {}
+func (ie *IfExpression) TokenLiteral() string        { return ie.Token.Literal }
+func (fl *FunctionLiteral) expressionNode()          {}
+func (fl *FunctionLiteral) TokenLiteral() string     { return fl.Token.Literal }
+func (ce *CallExpression) expressionNode()           {}
+func (ce *CallExpression) TokenLiteral() string      { return ce.Token.Literal }
+func (al *ArrayLiteral) expressionNode()             {}
+func (al *ArrayLiteral) TokenLiteral() string        { return al.Token.Literal }
+func (ie *IndexExpression) expressionNode()          {}
+func (ie *IndexExpression) TokenLiteral() string     { return ie.Token.Literal }
+func (se *SliceExpression) expressionNode()          {}
+func (se *SliceExpression) TokenLiteral() string     { return se.Token.Literal }
+func (hl *HashLiteral) expressionNode()              {}
+func (hl *HashLiteral) TokenLiteral() string         { return hl.Token.Literal }
+func (pe *PostfixExpression) expressionNode()        {}
+func (pe *PostfixExpression) TokenLiteral() string   { return pe.Token.Literal }
+func (sl *StringLiteral) expressionNode()            {}
+func (sl *StringLiteral) TokenLiteral() string       { return sl.Token.Literal }
+func (is *InterpolatedString) expressionNode()       {}
+func (is *InterpolatedString) TokenLiteral() string  { return is.Token.Literal }
+func (fl *FloatLiteral) expressionNode()             {}
+func (fl *FloatLiteral) TokenLiteral() string        { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string              { return fl.Token.Literal }
+func (le *LogicalExpression) expressionNode()        {}
+func (le *LogicalExpression) TokenLiteral() string   { return le.Token.Literal }
+func (ae *AssignExpression) expressionNode()         {}
+func (ae *AssignExpression) TokenLiteral() string    { return ae.Token.Literal }
+func (ws *WhileStatement) statementNode()            {}
+func (ws *WhileStatement) TokenLiteral() string      { return ws.Token.Literal }
+func (fs *ForStatement) statementNode()              {}
+func (fs *ForStatement) TokenLiteral() string        { return fs.Token.Literal }
+func (fs *ForInStatement) statementNode()            {}
+func (fs *ForInStatement) TokenLiteral() string      { return fs.Token.Literal }
+func (bs *BreakStatement) statementNode()            {}
+func (bs *BreakStatement) TokenLiteral() string      { return bs.Token.Literal }
+func (bs *BreakStatement) String() string            { return bs.Token.Literal + ";" }
+func (cs *ContinueStatement) statementNode()         {}
+func (cs *ContinueStatement) TokenLiteral() string   { return cs.Token.Literal }
+func (cs *ContinueStatement) String() string         { return cs.Token.Literal + ";" }
+func (be *BadExpression) expressionNode()            {}
+func (be *BadExpression) TokenLiteral() string       { return be.Token.Literal }
+func (bs *BadStatement) statementNode()              {}
+func (bs *BadStatement) TokenLiteral() string        { return bs.Token.Literal }
+
+// Positions
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
+func (ls *LetStatement) Pos() token.Position { return ls.Token.Pos }
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	if ls.Name != nil {
+		return ls.Name.End()
+	}
+	return ls.Token.End
+}
+func (rs *ReturnStatement) Pos() token.Position { return rs.Token.Pos }
+func (rs *ReturnStatement) End() token.Position {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return rs.Token.End
+}
+func (es *ExpressionStatement) Pos() token.Position {
+	if es.Expression != nil {
+		return es.Expression.Pos()
+	}
+	return es.Token.Pos
+}
+func (es *ExpressionStatement) End() token.Position {
+	if es.Expression != nil {
+		return es.Expression.End()
+	}
+	return es.Token.End
+}
+func (i *Identifier) Pos() token.Position      { return i.Token.Pos }
+func (i *Identifier) End() token.Position      { return i.Token.End }
+func (il *IntegerLiteral) Pos() token.Position { return il.Token.Pos }
+func (il *IntegerLiteral) End() token.Position { return il.Token.End }
+func (b *Boolean) Pos() token.Position         { return b.Token.Pos }
+func (b *Boolean) End() token.Position         { return b.Token.End }
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+func (pe *PrefixExpression) End() token.Position {
+	if pe.Right != nil {
+		return pe.Right.End()
+	}
+	return pe.Token.End
+}
+func (oe *InfixExpression) Pos() token.Position {
+	if oe.Left != nil {
+		return oe.Left.Pos()
+	}
+	return oe.Token.Pos
+}
+func (oe *InfixExpression) End() token.Position {
+	if oe.Right != nil {
+		return oe.Right.End()
+	}
+	return oe.Token.End
+}
+func (bs *BlockStatement) Pos() token.Position { return bs.Token.Pos }
+func (bs *BlockStatement) End() token.Position {
+	if bs.Rbrace.End.IsValid() {
+		return bs.Rbrace.End
+	}
+	if len(bs.Statements) > 0 {
+		return bs.Statements[len(bs.Statements)-1].End()
+	}
+	return bs.Token.End
+}
+func (ie *IfExpression) Pos() token.Position { return ie.Token.Pos }
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End
+}
+func (fl *FunctionLiteral) Pos() token.Position { return fl.Token.Pos }
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
+func (ce *CallExpression) Pos() token.Position { return ce.Function.Pos() }
+func (ce *CallExpression) End() token.Position {
+	if ce.Rparen.End.IsValid() {
+		return ce.Rparen.End
+	}
+	return ce.Token.End
+}
+func (al *ArrayLiteral) Pos() token.Position       { return al.Token.Pos }
+func (al *ArrayLiteral) End() token.Position       { return al.Rbrack.End }
+func (ie *IndexExpression) Pos() token.Position    { return ie.Left.Pos() }
+func (ie *IndexExpression) End() token.Position    { return ie.Rbrack.End }
+func (se *SliceExpression) Pos() token.Position    { return se.Left.Pos() }
+func (se *SliceExpression) End() token.Position    { return se.Rbrack.End }
+func (hl *HashLiteral) Pos() token.Position        { return hl.Token.Pos }
+func (hl *HashLiteral) End() token.Position        { return hl.Rbrace.End }
+func (pe *PostfixExpression) Pos() token.Position  { return pe.Left.Pos() }
+func (pe *PostfixExpression) End() token.Position  { return pe.Token.End }
+func (sl *StringLiteral) Pos() token.Position      { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Position      { return sl.Token.End }
+func (is *InterpolatedString) Pos() token.Position { return is.Token.Pos }
+func (is *InterpolatedString) End() token.Position {
+	return is.Literals[len(is.Literals)-1].End()
+}
+func (fl *FloatLiteral) Pos() token.Position      { return fl.Token.Pos }
+func (fl *FloatLiteral) End() token.Position      { return fl.Token.End }
+func (le *LogicalExpression) Pos() token.Position { return le.Left.Pos() }
+func (le *LogicalExpression) End() token.Position {
+	if le.Right != nil {
+		return le.Right.End()
+	}
+	return le.Token.End
+}
+func (ae *AssignExpression) Pos() token.Position { return ae.Target.Pos() }
+func (ae *AssignExpression) End() token.Position {
+	if ae.Value != nil {
+		return ae.Value.End()
+	}
+	return ae.Token.End
+}
+func (ws *WhileStatement) Pos() token.Position { return ws.Token.Pos }
+func (ws *WhileStatement) End() token.Position {
+	if ws.Body != nil {
+		return ws.Body.End()
+	}
+	return ws.Token.End
+}
+func (fs *ForStatement) Pos() token.Position { return fs.Token.Pos }
+func (fs *ForStatement) End() token.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+	return fs.Token.End
+}
+func (fs *ForInStatement) Pos() token.Position { return fs.Token.Pos }
+func (fs *ForInStatement) End() token.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+	return fs.Token.End
+}
+func (bs *BreakStatement) Pos() token.Position    { return bs.Token.Pos }
+func (bs *BreakStatement) End() token.Position    { return bs.Token.End }
+func (cs *ContinueStatement) Pos() token.Position { return cs.Token.Pos }
+func (cs *ContinueStatement) End() token.Position { return cs.Token.End }
+func (be *BadExpression) Pos() token.Position     { return be.Token.Pos }
+func (be *BadExpression) End() token.Position     { return be.To }
+func (bs *BadStatement) Pos() token.Position      { return bs.Token.Pos }
+func (bs *BadStatement) End() token.Position      { return bs.To }
 
 // String methods
 func (oe *InfixExpression) String() string {
@@ -122,6 +485,9 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+// IsConst reports whether the statement declares an immutable binding
+func (ls *LetStatement) IsConst() bool { return ls.Token.Type == token.CONST }
+
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(ls.TokenLiteral() + " ")
@@ -151,3 +517,229 @@ func (es *ExpressionStatement) String() string {
 }
 
 func (i *Identifier) String() string { return i.Value }
+
+func (be *BadExpression) String() string { return "<bad expression>" }
+func (bs *BadStatement) String() string  { return "<bad statement>" }
+
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("{")
+	for _, s := range bs.Statements {
+		out.WriteString(" ")
+		out.WriteString(s.String())
+	}
+	out.WriteString(" }")
+	return out.String()
+}
+
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("if ")
+	out.WriteString(ie.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ie.Consequence.String())
+	if ie.Alternative != nil {
+		out.WriteString(" else ")
+		out.WriteString(ie.Alternative.String())
+	}
+	return out.String()
+}
+
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(fl.Body.String())
+	return out.String()
+}
+
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+	return out.String()
+}
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	out.WriteString("])")
+	return out.String()
+}
+
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+func (pe *PostfixExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(pe.Operator)
+	out.WriteString(")")
+	return out.String()
+}
+
+func (sl *StringLiteral) String() string { return quote(sl.Value) }
+
+// quote returns s as a double-quoted string literal that lexes back to s
+func quote(s string) string {
+	return `"` + quoteBody(s) + `"`
+}
+
+// quoteBody escapes s for use between the quotes of a string literal
+func quoteBody(s string) string {
+	var out bytes.Buffer
+	for i, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '$':
+			if strings.HasPrefix(s[i+1:], "{") {
+				out.WriteString(`\$`)
+			} else {
+				out.WriteRune(r)
+			}
+		default:
+			if r < ' ' || r == 0x7f {
+				fmt.Fprintf(&out, `\u{%x}`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	return out.String()
+}
+
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	out.WriteByte('"')
+	for i, literal := range is.Literals {
+		out.WriteString(quoteBody(literal.Value))
+		if i < len(is.Expressions) {
+			out.WriteString("${")
+			out.WriteString(is.Expressions[i].String())
+			out.WriteString("}")
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+func (le *LogicalExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(le.Left.String())
+	out.WriteString(" " + le.Operator + " ")
+	out.WriteString(le.Right.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ws.TokenLiteral() + " ")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+	return out.String()
+}
+
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+	clauses := []string{"", "", ""}
+	if fs.Init != nil {
+		clauses[0] = strings.TrimSuffix(fs.Init.String(), ";")
+	}
+	if fs.Condition != nil {
+		clauses[1] = fs.Condition.String()
+	}
+	if fs.Post != nil {
+		clauses[2] = fs.Post.String()
+	}
+	out.WriteString(fs.TokenLiteral() + " (")
+	out.WriteString(strings.Join(clauses, "; "))
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+	return out.String()
+}
+
+func (fs *ForInStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(fs.TokenLiteral() + " ")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(" ")
+	out.WriteString(fs.Body.String())
+	return out.String()
+}
@@ -25,3 +25,47 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestPositions(t *testing.T) {
+	pos := func(offset, column int) token.Position {
+		return token.Position{Offset: offset, Line: 1, Column: column}
+	}
+	// skibidi x = -y + 1
+	infix := &InfixExpression{
+		Token: token.Token{Type: token.ADD, Literal: "+", Pos: pos(15, 16), End: pos(16, 17)},
+		Left: &PrefixExpression{
+			Token:    token.Token{Type: token.SUB, Literal: "-", Pos: pos(12, 13), End: pos(13, 14)},
+			Operator: "-",
+			Right: &Identifier{
+				Token: token.Token{Type: token.IDENT, Literal: "y", Pos: pos(13, 14), End: pos(14, 15)},
+				Value: "y",
+			},
+		},
+		Operator: "+",
+		Right: &IntegerLiteral{
+			Token: token.Token{Type: token.INT, Literal: "1", Pos: pos(17, 18), End: pos(18, 19)},
+			Value: 1,
+		},
+	}
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token: token.Token{Type: token.LET, Literal: "skibidi", Pos: pos(0, 1), End: pos(7, 8)},
+				Name: &Identifier{
+					Token: token.Token{Type: token.IDENT, Literal: "x", Pos: pos(8, 9), End: pos(9, 10)},
+					Value: "x",
+				},
+				Value: infix,
+			},
+		},
+	}
+	if program.Pos() != pos(0, 1) {
+		t.Errorf("program.Pos() wrong. got=%+v", program.Pos())
+	}
+	if program.End() != pos(18, 19) {
+		t.Errorf("program.End() wrong. got=%+v", program.End())
+	}
+	if infix.Pos() != pos(12, 13) {
+		t.Errorf("infix.Pos() wrong. got=%+v", infix.Pos())
+	}
+}
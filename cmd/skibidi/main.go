@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"skibidilang/repl"
+	"strings"
+)
+
+const usage = `usage: skibidi <command> [arguments]
+       skibidi file.skb [arguments]
+
+commands:
+  repl                       start an interactive session
+  run file.skb [arguments]   run a script, its arguments are in args
+
+exit status:
+  0  success, or the status passed to exit
+  1  runtime error
+  2  bad command line or unreadable script
+  3  syntax or compile error
+`
+
+// exit statuses
+const (
+	exitOK      = 0
+	exitError   = 1 // the script failed while running
+	exitUsage   = 2 // bad command line, or the script cannot be read
+	exitInvalid = 3 // the script has syntax or compile errors
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command in args and returns the exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "repl":
+		if len(args) > 1 {
+			fmt.Fprintln(stderr, "usage: skibidi repl")
+			return exitUsage
+		}
+		fmt.Fprintln(stdout, "skibidi repl, type :help for help")
+		return repl.Start(stdin, stdout)
+	case "run":
+		if len(args) < 2 {
+			fmt.Fprintln(stderr, "usage: skibidi run file.skb [arguments]")
+			return exitUsage
+		}
+		return runScript(args[1], args[2:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	// a script started through a #! line is passed as a path
+	if strings.ContainsRune(args[0], filepath.Separator) || filepath.Ext(args[0]) == ".skb" {
+		return runScript(args[0], args[1:], stdout, stderr)
+	}
+	fmt.Fprintf(stderr, "skibidi: unknown command %q\n\n%s", args[0], usage)
+	return exitUsage
+}
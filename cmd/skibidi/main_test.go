@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stdin  string
+		status int
+		stdout string
+		stderr string
+	}{
+		{nil, "", 2, "", usage},
+		{[]string{"help"}, "", 0, usage, ""},
+		{[]string{"bogus"}, "", 2, "", "skibidi: unknown command \"bogus\"\n\n" + usage},
+		{[]string{"repl", "x"}, "", 2, "", "usage: skibidi repl\n"},
+		{[]string{"repl"}, "1 + 1\n", 0, "skibidi repl, type :help for help\n>> 2\n>> \n", ""},
+		{[]string{"repl"}, "1\nexit(4)\n2\n", 4, "skibidi repl, type :help for help\n>> 1\n>> ", ""},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+		if status != tt.status {
+			t.Errorf("%q: wrong exit status. want=%d, got=%d", tt.args, tt.status, status)
+		}
+		if stdout.String() != tt.stdout {
+			t.Errorf("%q: wrong output. want=%q, got=%q", tt.args, tt.stdout, stdout.String())
+		}
+		if stderr.String() != tt.stderr {
+			t.Errorf("%q: wrong error output. want=%q, got=%q", tt.args, tt.stderr, stderr.String())
+		}
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	dir := t.TempDir()
+	script := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(src), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	hello := script("hello.skb", "#!/usr/bin/env skibidi\nrizz a in args { puts(\"hi ${a}\") }\n")
+	syntax := script("syntax.skb", "skibidi x = ;\nskibidi = 2;\n")
+	undefined := script("undefined.skb", "puts(1);\nputs(y);\n")
+	failing := script("failing.skb", "skibidi f = ohio(x) {\n  x / 0\n};\nputs(\"before\");\nf(1);\nputs(\"after\");\n")
+	exiting := script("exiting", "#!/usr/bin/env skibidi\nputs(len(args));\nexit(len(args));\nputs(\"unreachable\");\n")
+	outOfRange := script("range.skb", "exit(256);\n")
+	missing := filepath.Join(dir, "missing.skb")
+
+	tests := []struct {
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{[]string{"run", hello, "a", "b"}, 0, "hi a\nhi b\n", ""},
+		{[]string{hello}, 0, "", ""},
+		{[]string{"run", syntax}, 3, "", syntax + ":1:13: no prefix parse function for ; found\n" +
+			syntax + ":2:9: expected next token to be IDENT, got = instead\n"},
+		{[]string{"run", undefined}, 3, "", undefined + ":2:6: identifier not found: y\n"},
+		{[]string{"run", failing}, 1, "before\n", failing + ":2:3: division by zero\n"},
+		{[]string{exiting, "x", "y"}, 2, "2\n", ""},
+		{[]string{"run", exiting}, 0, "0\n", ""},
+		{[]string{"run", outOfRange}, 1, "", outOfRange + ":1:1: exit status must be between 0 and 255, got 256\n"},
+		{[]string{"run", missing}, 2, "", "skibidi: open " + missing + ": no such file or directory\n"},
+		{[]string{"run"}, 2, "", "usage: skibidi run file.skb [arguments]\n"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+		if status != tt.status {
+			t.Errorf("%q: wrong exit status. want=%d, got=%d", tt.args, tt.status, status)
+		}
+		if stdout.String() != tt.stdout {
+			t.Errorf("%q: wrong output. want=%q, got=%q", tt.args, tt.stdout, stdout.String())
+		}
+		if stderr.String() != tt.stderr {
+			t.Errorf("%q: wrong error output. want=%q, got=%q", tt.args, tt.stderr, stderr.String())
+		}
+	}
+}
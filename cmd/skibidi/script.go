@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"skibidilang/compiler"
+	"skibidilang/lexer"
+	"skibidilang/object"
+	"skibidilang/parser"
+	"skibidilang/vm"
+)
+
+// runScript compiles the script in filename and runs it on the vm. The
+// script sees scriptArgs as an array of strings in the global args.
+func runScript(filename string, scriptArgs []string, stdout, stderr io.Writer) int {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(stderr, "skibidi: %s\n", err)
+		return exitUsage
+	}
+
+	l := lexer.NewFile(filename, string(src))
+	l.SkipShebang()
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		for _, msg := range errors {
+			fmt.Fprintln(stderr, msg)
+		}
+		return exitInvalid
+	}
+
+	comp := compiler.New()
+	argsSymbol := comp.SymbolTable().Define("args", false)
+	if err := comp.Compile(program); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitInvalid
+	}
+
+	elements := make([]object.Object, len(scriptArgs))
+	for i, arg := range scriptArgs {
+		elements[i] = &object.String{Value: arg}
+	}
+	globals := make([]object.Object, vm.GlobalsSize)
+	globals[argsSymbol.Index] = &object.Array{Elements: elements}
+
+	previous := object.Stdout
+	object.Stdout = stdout
+	defer func() { object.Stdout = previous }()
+
+	machine := vm.NewWithGlobalsStore(comp.Bytecode(), globals)
+	if err := machine.Run(); err != nil {
+		if exit, ok := err.(*object.Error); ok && exit.Exit {
+			return exit.Status
+		}
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,272 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"skibidilang/token"
+)
+
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+	OpDup
+	OpRot
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpBitAndNot
+	OpShl
+	OpShr
+
+	OpTrue
+	OpFalse
+	OpNull
+
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpLessEqual
+	OpGreaterThan
+	OpGreaterEqual
+
+	OpMinus
+	OpBang
+	OpBitNot
+
+	OpJumpNotTruthy
+	OpJump
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetBuiltin
+	OpGetFree
+	OpSetFree
+	OpCaptureLocal
+	OpCaptureFree
+
+	OpArray
+	OpHash
+	OpIndex
+	OpSetIndex
+	OpSlice
+	OpConcat
+
+	OpIter
+	OpIterNext
+
+	OpCall
+	OpReturnValue
+	OpReturn
+	OpClosure
+)
+
+// Definition describes an opcode for the encoder and the disassembler
+type Definition struct {
+	Name          string
+	OperandWidths []int // width of each operand in bytes
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}}, // index into the constant pool
+	OpPop:      {"OpPop", []int{}},
+	OpDup:      {"OpDup", []int{1}}, // number of values to duplicate
+	OpRot:      {"OpRot", []int{1}}, // number of values to move the top one below
+
+	OpAdd:       {"OpAdd", []int{}},
+	OpSub:       {"OpSub", []int{}},
+	OpMul:       {"OpMul", []int{}},
+	OpDiv:       {"OpDiv", []int{}},
+	OpMod:       {"OpMod", []int{}},
+	OpPow:       {"OpPow", []int{}},
+	OpBitAnd:    {"OpBitAnd", []int{}},
+	OpBitOr:     {"OpBitOr", []int{}},
+	OpBitXor:    {"OpBitXor", []int{}},
+	OpBitAndNot: {"OpBitAndNot", []int{}},
+	OpShl:       {"OpShl", []int{}},
+	OpShr:       {"OpShr", []int{}},
+
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+	OpNull:  {"OpNull", []int{}},
+
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+
+	OpMinus:  {"OpMinus", []int{}},
+	OpBang:   {"OpBang", []int{}},
+	OpBitNot: {"OpBitNot", []int{}},
+
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}}, // jump target
+	OpJump:          {"OpJump", []int{2}},          // jump target
+
+	OpGetGlobal:    {"OpGetGlobal", []int{2}},    // global index
+	OpSetGlobal:    {"OpSetGlobal", []int{2}},    // global index
+	OpGetLocal:     {"OpGetLocal", []int{1}},     // local index
+	OpSetLocal:     {"OpSetLocal", []int{1}},     // local index
+	OpGetBuiltin:   {"OpGetBuiltin", []int{1}},   // builtin index
+	OpGetFree:      {"OpGetFree", []int{1}},      // free variable index
+	OpSetFree:      {"OpSetFree", []int{1}},      // free variable index
+	OpCaptureLocal: {"OpCaptureLocal", []int{1}}, // local index, pushes the cell holding it
+	OpCaptureFree:  {"OpCaptureFree", []int{1}},  // free variable index, pushes its cell
+
+	OpArray:    {"OpArray", []int{2}}, // number of elements
+	OpHash:     {"OpHash", []int{2}},  // number of keys and values
+	OpIndex:    {"OpIndex", []int{}},
+	OpSetIndex: {"OpSetIndex", []int{}},
+	OpSlice:    {"OpSlice", []int{}},
+	OpConcat:   {"OpConcat", []int{2}}, // number of parts
+
+	OpIter:     {"OpIter", []int{}},
+	OpIterNext: {"OpIterNext", []int{2}}, // jump target once the iterator is exhausted
+
+	OpCall:        {"OpCall", []int{1}}, // number of arguments
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+	OpClosure:     {"OpClosure", []int{2, 1}}, // constant index, number of free variables
+}
+
+// Lookup returns the definition of op
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make encodes an instruction. Operands are big endian.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction and returns them
+// with the number of bytes read
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
+
+// String disassembles the instructions, one per line
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			return out.String()
+		}
+		if i+1+operandsWidth(def) > len(ins) {
+			fmt.Fprintf(&out, "ERROR: truncated %s at %04d\n", def.Name, i)
+			return out.String()
+		}
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+		i += 1 + read
+	}
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), operandCount)
+	}
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
+
+func operandsWidth(def *Definition) int {
+	width := 0
+	for _, w := range def.OperandWidths {
+		width += w
+	}
+	return width
+}
+
+// LineEntry maps the instructions starting at Offset to the source
+// position they were compiled from
+type LineEntry struct {
+	Offset int
+	Pos    token.Position
+}
+
+// LineTable maps instruction offsets to source positions, its entries are
+// sorted by offset
+type LineTable []LineEntry
+
+// Lookup returns the source position of the instruction at offset
+func (lt LineTable) Lookup(offset int) token.Position {
+	var pos token.Position
+	for _, entry := range lt {
+		if entry.Offset > offset {
+			break
+		}
+		pos = entry.Pos
+	}
+	return pos
+}
@@ -0,0 +1,920 @@
+package compiler
+
+import (
+	"fmt"
+	"math"
+	"skibidilang/ast"
+	"skibidilang/code"
+	"skibidilang/object"
+	"skibidilang/token"
+	"strings"
+)
+
+// Error is a problem found while compiling, such as an undefined variable
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if e.Pos.IsValid() || e.Pos.Filename != "" {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return e.Msg
+}
+
+var binaryOperators = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"**": code.OpPow,
+	"&":  code.OpBitAnd,
+	"|":  code.OpBitOr,
+	"^":  code.OpBitXor,
+	"&^": code.OpBitAndNot,
+	"<<": code.OpShl,
+	">>": code.OpShr,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	"<=": code.OpLessEqual,
+	">":  code.OpGreaterThan,
+	">=": code.OpGreaterEqual,
+}
+
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+	scopes      []CompilationScope
+	scopeIndex  int
+	pos         token.Position // position of the node being compiled
+	mainReturns []int          // jumps of goon in the main program, to its end
+}
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+// CompilationScope holds the instructions of the function being compiled
+type CompilationScope struct {
+	instructions        code.Instructions
+	lines               code.LineTable
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+	loops               []*loop
+}
+
+// loop collects the jumps of yeet and mew statements, which are patched
+// once the loop is compiled
+type loop struct {
+	breaks    []int
+	continues []int
+}
+
+// Bytecode is the result of compiling a program
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	Lines        code.LineTable
+	GlobalNames  []string // names of the globals by slot, for errors
+}
+
+func New() *Compiler {
+	symbolTable := NewSymbolTable()
+	for i, builtin := range object.Builtins {
+		symbolTable.DefineBuiltin(i, builtin.Name)
+	}
+	return &Compiler{
+		symbolTable: symbolTable,
+		scopes:      []CompilationScope{{}},
+	}
+}
+
+// NewWithState returns a compiler continuing from the symbols and
+// constants of an earlier one, to compile programs one after the other
+// against the same globals
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	compiler := New()
+	compiler.symbolTable = s
+	compiler.constants = constants
+	return compiler
+}
+
+// SymbolTable returns the table of global symbols
+func (c *Compiler) SymbolTable() *SymbolTable {
+	return c.symbolTable
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	previous := c.pos
+	c.pos = node.Pos()
+	defer func() { c.pos = previous }()
+
+	switch node := node.(type) {
+	// Statements
+	case *ast.Program:
+		c.declare(node)
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+		if !c.jumpsFit() {
+			return c.errorf(node, "too much code in program")
+		}
+		for _, jump := range c.mainReturns {
+			c.changeOperand(jump, len(c.currentInstructions()))
+		}
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	case *ast.LetStatement:
+		return c.compileLetStatement(node)
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			c.emit(code.OpNull)
+		} else if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		if c.scopeIndex > 0 {
+			c.emit(code.OpReturnValue)
+			break
+		}
+		// the main program ends with the value as the last one popped
+		c.emit(code.OpPop)
+		c.mainReturns = append(c.mainReturns, c.emit(code.OpJump, 9999))
+	case *ast.WhileStatement:
+		return c.compileWhileStatement(node)
+	case *ast.ForStatement:
+		return c.compileForStatement(node)
+	case *ast.ForInStatement:
+		return c.compileForInStatement(node)
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		loops := c.scopes[c.scopeIndex].loops
+		if len(loops) == 0 {
+			return c.errorf(node, "%s is not in a loop", node.TokenLiteral())
+		}
+		jump := c.emit(code.OpJump, 9999)
+		current := loops[len(loops)-1]
+		if _, ok := node.(*ast.BreakStatement); ok {
+			current.breaks = append(current.breaks, jump)
+		} else {
+			current.continues = append(current.continues, jump)
+		}
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		return c.emitConstant(node, &object.Integer{Value: node.Value})
+	case *ast.FloatLiteral:
+		return c.emitConstant(node, &object.Float{Value: node.Value})
+	case *ast.StringLiteral:
+		return c.emitConstant(node, &object.String{Value: node.Value})
+	case *ast.InterpolatedString:
+		if len(node.Literals)+len(node.Expressions) > math.MaxUint16 {
+			return c.errorf(node, "too many parts in string")
+		}
+		for i, literal := range node.Literals {
+			if err := c.emitConstant(literal, &object.String{Value: literal.Value}); err != nil {
+				return err
+			}
+			if i < len(node.Expressions) {
+				if err := c.Compile(node.Expressions[i]); err != nil {
+					return err
+				}
+			}
+		}
+		c.emit(code.OpConcat, len(node.Literals)+len(node.Expressions))
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+		if !ok {
+			return c.errorf(node, "identifier not found: %s", node.Value)
+		}
+		c.loadSymbol(symbol)
+	case *ast.PrefixExpression:
+		if node.Operator == "++" || node.Operator == "--" {
+			return c.compileIncDec(node.Operator, node.Right, true)
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		switch node.Operator {
+		case "!":
+			c.emit(code.OpBang)
+		case "-":
+			c.emit(code.OpMinus)
+		case "~":
+			c.emit(code.OpBitNot)
+		default:
+			return c.errorf(node, "unknown operator %s", node.Operator)
+		}
+	case *ast.PostfixExpression:
+		return c.compileIncDec(node.Operator, node.Left, false)
+	case *ast.InfixExpression:
+		op, ok := binaryOperators[node.Operator]
+		if !ok {
+			return c.errorf(node, "unknown operator %s", node.Operator)
+		}
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+	case *ast.LogicalExpression:
+		return c.compileLogicalExpression(node)
+	case *ast.AssignExpression:
+		return c.compileAssignExpression(node)
+	case *ast.IfExpression:
+		return c.compileIfExpression(node)
+	case *ast.FunctionLiteral:
+		return c.compileFunction(node, "")
+	case *ast.CallExpression:
+		if len(node.Arguments) > math.MaxUint8 {
+			return c.errorf(node, "too many arguments")
+		}
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+		for _, a := range node.Arguments {
+			if err := c.Compile(a); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments))
+	case *ast.ArrayLiteral:
+		if len(node.Elements) > math.MaxUint16 {
+			return c.errorf(node, "too many array elements")
+		}
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+	case *ast.HashLiteral:
+		if len(node.Pairs)*2 > math.MaxUint16 {
+			return c.errorf(node, "too many hash pairs")
+		}
+		// in source order, which is the order the hash remembers
+		for _, pair := range node.Pairs {
+			if err := c.Compile(pair.Key); err != nil {
+				return err
+			}
+			if err := c.Compile(pair.Value); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(node.Pairs)*2)
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+	case *ast.SliceExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		// a missing bound is passed as null
+		for _, bound := range []ast.Expression{node.Low, node.High} {
+			if bound == nil {
+				c.emit(code.OpNull)
+			} else if err := c.Compile(bound); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpSlice)
+	case *ast.BadExpression, *ast.BadStatement:
+		return c.errorf(node, "cannot compile code with syntax errors")
+	default:
+		return c.errorf(node, "cannot compile %T", node)
+	}
+	return nil
+}
+
+func (c *Compiler) compileLetStatement(node *ast.LetStatement) error {
+	if symbol, ok := c.symbolTable.Lookup(node.Name.Value); ok && symbol.Constant {
+		return c.errorf(node.Name, "cannot redeclare constant %s", node.Name.Value)
+	}
+	if fn, ok := node.Value.(*ast.FunctionLiteral); ok {
+		// defined first, so that the function can call itself
+		symbol := c.symbolTable.Define(node.Name.Value, node.IsConst())
+		if err := c.compileFunction(fn, node.Name.Value); err != nil {
+			return err
+		}
+		return c.setSymbol(node.Name, symbol)
+	}
+	if err := c.Compile(node.Value); err != nil {
+		return err
+	}
+	// defined after the value, which still sees an outer binding of the name
+	symbol := c.symbolTable.Define(node.Name.Value, node.IsConst())
+	return c.setSymbol(node.Name, symbol)
+}
+
+func (c *Compiler) compileWhileStatement(node *ast.WhileStatement) error {
+	start := len(c.currentInstructions())
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+	exit := c.emit(code.OpJumpNotTruthy, 9999)
+	c.enterLoop()
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpJump, start)
+	end := len(c.currentInstructions())
+	c.changeOperand(exit, end)
+	c.leaveLoop(end, start)
+	return nil
+}
+
+func (c *Compiler) compileForStatement(node *ast.ForStatement) error {
+	if node.Init != nil {
+		if err := c.Compile(node.Init); err != nil {
+			return err
+		}
+	}
+	start := len(c.currentInstructions())
+	exit := -1
+	if node.Condition != nil {
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+		exit = c.emit(code.OpJumpNotTruthy, 9999)
+	}
+	c.enterLoop()
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	next := len(c.currentInstructions())
+	if node.Post != nil {
+		if err := c.Compile(node.Post); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	}
+	c.emit(code.OpJump, start)
+	end := len(c.currentInstructions())
+	if exit >= 0 {
+		c.changeOperand(exit, end)
+	}
+	c.leaveLoop(end, next)
+	return nil
+}
+
+// compileForInStatement keeps an iterator on the stack while the loop
+// runs. OpIterNext removes it when it is exhausted, yeet jumps to an OpPop
+// removing it.
+func (c *Compiler) compileForInStatement(node *ast.ForInStatement) error {
+	if err := c.Compile(node.Iterable); err != nil {
+		return err
+	}
+	// errors about the iterable point at it rather than at the loop
+	c.pos = node.Iterable.Pos()
+	c.emit(code.OpIter)
+	c.pos = node.Pos()
+	next := c.emit(code.OpIterNext, 9999)
+	if symbol, ok := c.symbolTable.Lookup(node.Variable.Value); ok && symbol.Constant {
+		return c.errorf(node.Variable, "cannot assign to constant %s", node.Variable.Value)
+	}
+	symbol := c.symbolTable.Define(node.Variable.Value, false)
+	if err := c.storeSymbol(node.Variable, symbol); err != nil {
+		return err
+	}
+	c.enterLoop()
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpJump, next)
+	breakTarget := len(c.currentInstructions())
+	c.emit(code.OpPop)
+	c.changeOperand(next, len(c.currentInstructions()))
+	c.leaveLoop(breakTarget, next)
+	return nil
+}
+
+func (c *Compiler) enterLoop() {
+	scope := &c.scopes[c.scopeIndex]
+	scope.loops = append(scope.loops, &loop{})
+}
+
+// leaveLoop points the yeet and mew jumps of the innermost loop at
+// breakTarget and continueTarget
+func (c *Compiler) leaveLoop(breakTarget, continueTarget int) {
+	scope := &c.scopes[c.scopeIndex]
+	current := scope.loops[len(scope.loops)-1]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+	for _, jump := range current.breaks {
+		c.changeOperand(jump, breakTarget)
+	}
+	for _, jump := range current.continues {
+		c.changeOperand(jump, continueTarget)
+	}
+}
+
+// compileLogicalExpression compiles jumps around the right operand, which
+// is only evaluated when the left one does not decide the result
+func (c *Compiler) compileLogicalExpression(node *ast.LogicalExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	var jumps []int
+	if node.Operator == "&&" {
+		jumps = append(jumps, c.emit(code.OpJumpNotTruthy, 9999))
+	} else {
+		right := c.emit(code.OpJumpNotTruthy, 9999)
+		c.emit(code.OpTrue)
+		jumps = append(jumps, c.emit(code.OpJump, 9999))
+		c.changeOperand(right, len(c.currentInstructions()))
+	}
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	isFalse := c.emit(code.OpJumpNotTruthy, 9999)
+	c.emit(code.OpTrue)
+	end := c.emit(code.OpJump, 9999)
+	falseTarget := len(c.currentInstructions())
+	c.changeOperand(isFalse, falseTarget)
+	c.emit(code.OpFalse)
+	for _, jump := range jumps {
+		if c.currentInstructions()[jump] == byte(code.OpJumpNotTruthy) {
+			c.changeOperand(jump, falseTarget)
+		} else {
+			c.changeOperand(jump, len(c.currentInstructions()))
+		}
+	}
+	c.changeOperand(end, len(c.currentInstructions()))
+	return nil
+}
+
+func (c *Compiler) compileAssignExpression(node *ast.AssignExpression) error {
+	compound := node.Operator != "="
+	var op code.Opcode
+	if compound {
+		var ok bool
+		op, ok = binaryOperators[strings.TrimSuffix(node.Operator, "=")]
+		if !ok {
+			return c.errorf(node, "unknown operator %s", node.Operator)
+		}
+	}
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(target.Value)
+		if !ok {
+			return c.errorf(target, "identifier not found: %s", target.Value)
+		}
+		if compound {
+			c.loadSymbol(symbol)
+		}
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(op)
+		}
+		if err := c.storeSymbol(target, symbol); err != nil {
+			return err
+		}
+		c.loadSymbol(symbol)
+	case *ast.IndexExpression:
+		if err := c.Compile(target.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(target.Index); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(code.OpDup, 2)
+			c.emit(code.OpIndex)
+		}
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(op)
+		}
+		c.emit(code.OpSetIndex)
+	default:
+		return c.errorf(node.Target, "cannot assign to %s", node.Target)
+	}
+	return nil
+}
+
+// compileIncDec compiles ++ and --, the prefix forms result in the new
+// value and the postfix forms in the old one
+func (c *Compiler) compileIncDec(operator string, target ast.Expression, prefix bool) error {
+	op := code.OpAdd
+	if operator == "--" {
+		op = code.OpSub
+	}
+	one := &object.Integer{Value: 1}
+	switch target := target.(type) {
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(target.Value)
+		if !ok {
+			return c.errorf(target, "identifier not found: %s", target.Value)
+		}
+		c.loadSymbol(symbol)
+		if !prefix {
+			// the old value stays below the new one
+			c.loadSymbol(symbol)
+		}
+		if err := c.emitConstant(target, one); err != nil {
+			return err
+		}
+		c.emit(op)
+		if err := c.storeSymbol(target, symbol); err != nil {
+			return err
+		}
+		if prefix {
+			c.loadSymbol(symbol)
+		}
+	case *ast.IndexExpression:
+		if err := c.Compile(target.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(target.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpDup, 2)
+		c.emit(code.OpIndex)
+		if !prefix {
+			// a copy of the old value goes below the indexed value and the
+			// index, it stays once the new value is popped
+			c.emit(code.OpDup, 1)
+			c.emit(code.OpRot, 3)
+		}
+		if err := c.emitConstant(target, one); err != nil {
+			return err
+		}
+		c.emit(op)
+		c.emit(code.OpSetIndex)
+		if !prefix {
+			c.emit(code.OpPop)
+		}
+	default:
+		return c.errorf(target, "invalid operand for %s: %s is not assignable", operator, target)
+	}
+	return nil
+}
+
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, 9999)
+	if err := c.compileBlockValue(node.Consequence); err != nil {
+		return err
+	}
+	jump := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthy, len(c.currentInstructions()))
+	switch alternative := node.Alternative.(type) {
+	case nil:
+		c.emit(code.OpNull)
+	case *ast.BlockStatement:
+		if err := c.compileBlockValue(alternative); err != nil {
+			return err
+		}
+	default:
+		if err := c.Compile(alternative); err != nil {
+			return err
+		}
+	}
+	c.changeOperand(jump, len(c.currentInstructions()))
+	return nil
+}
+
+// compileBlockValue compiles a block that leaves a value on the stack: the
+// value of its last statement if that is an expression, null otherwise
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+	n := len(block.Statements)
+	if n > 0 && c.lastInstructionIs(code.OpPop) {
+		if _, ok := block.Statements[n-1].(*ast.ExpressionStatement); ok {
+			c.removeLastPop()
+			return nil
+		}
+	}
+	c.emit(code.OpNull)
+	return nil
+}
+
+func (c *Compiler) compileFunction(node *ast.FunctionLiteral, name string) error {
+	c.enterScope()
+	for _, p := range node.Parameters {
+		c.symbolTable.Define(p.Value, false)
+	}
+	c.declare(node.Body)
+	if err := c.Compile(node.Body); err != nil {
+		c.leaveScope()
+		return err
+	}
+	n := len(node.Body.Statements)
+	if n > 0 && c.lastInstructionIs(code.OpPop) {
+		if _, ok := node.Body.Statements[n-1].(*ast.ExpressionStatement); ok {
+			c.replaceLastPopWithReturn()
+		}
+	}
+	if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpReturn)
+	}
+	if !c.jumpsFit() {
+		c.leaveScope()
+		return c.errorf(node, "too much code in function")
+	}
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.numDefinitions
+	localNames := c.symbolTable.names()
+	instructions, lines := c.leaveScope()
+	if numLocals > math.MaxUint8 || len(freeSymbols) > math.MaxUint8 {
+		return c.errorf(node, "too many variables in function")
+	}
+	freeNames := make([]string, len(freeSymbols))
+	for i, s := range freeSymbols {
+		c.captureSymbol(s)
+		freeNames[i] = s.Name
+	}
+	compiledFn := &object.CompiledFunction{
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		Name:          name,
+		Lines:         lines,
+		LocalNames:    localNames,
+		FreeNames:     freeNames,
+	}
+	index, err := c.addConstant(node, compiledFn)
+	if err != nil {
+		return err
+	}
+	c.emit(code.OpClosure, index, len(freeSymbols))
+	return nil
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case BuiltinScope:
+		c.emit(code.OpGetBuiltin, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	}
+}
+
+// captureSymbol pushes the cell holding the variable s, a local or free
+// variable of the current function, for a closure to capture
+func (c *Compiler) captureSymbol(s Symbol) {
+	switch s.Scope {
+	case LocalScope:
+		c.emit(code.OpCaptureLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpCaptureFree, s.Index)
+	}
+}
+
+// storeSymbol pops the value on top of the stack into the variable s, which
+// must not be a constant
+func (c *Compiler) storeSymbol(node ast.Node, s Symbol) error {
+	if s.Constant {
+		return c.errorf(node, "cannot assign to constant %s", s.Name)
+	}
+	return c.setSymbol(node, s)
+}
+
+// setSymbol pops the value on top of the stack into the variable s
+func (c *Compiler) setSymbol(node ast.Node, s Symbol) error {
+	switch s.Scope {
+	case GlobalScope:
+		if s.Index > math.MaxUint16 {
+			return c.errorf(node, "too many global variables")
+		}
+		c.emit(code.OpSetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpSetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpSetFree, s.Index)
+	case BuiltinScope:
+		return c.errorf(node, "cannot assign to builtin %s", s.Name)
+	}
+	return nil
+}
+
+func (c *Compiler) emitConstant(node ast.Node, obj object.Object) error {
+	index, err := c.addConstant(node, obj)
+	if err != nil {
+		return err
+	}
+	c.emit(code.OpConstant, index)
+	return nil
+}
+
+func (c *Compiler) addConstant(node ast.Node, obj object.Object) (int, error) {
+	if len(c.constants) > math.MaxUint16 {
+		return 0, c.errorf(node, "too many constants")
+	}
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1, nil
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+	c.setLastInstruction(op, pos)
+	return pos
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	scope := &c.scopes[c.scopeIndex]
+	posNewInstruction := len(scope.instructions)
+	if n := len(scope.lines); n == 0 || scope.lines[n-1].Pos != c.pos {
+		scope.lines = append(scope.lines, code.LineEntry{Offset: posNewInstruction, Pos: c.pos})
+	}
+	scope.instructions = append(scope.instructions, ins...)
+	return posNewInstruction
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	scope := &c.scopes[c.scopeIndex]
+	last := scope.lastInstruction
+	scope.instructions = scope.instructions[:last.Position]
+	for len(scope.lines) > 0 && scope.lines[len(scope.lines)-1].Offset >= last.Position {
+		scope.lines = scope.lines[:len(scope.lines)-1]
+	}
+	scope.lastInstruction = scope.previousInstruction
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+	for i := 0; i < len(newInstruction); i++ {
+		ins[pos+i] = newInstruction[i]
+	}
+}
+
+// jumpsFit reports whether every offset in the current instructions, up to
+// the one just past the end, fits in the 16 bit operands of the jumps.
+// Larger offsets would be truncated by code.Make.
+func (c *Compiler) jumpsFit() bool {
+	return len(c.currentInstructions()) <= math.MaxUint16
+}
+
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	newInstruction := code.Make(op, operand)
+	c.replaceInstruction(opPos, newInstruction)
+}
+
+// declare records the names bound by lets in node in the symbol table,
+// leaving out nested functions, whose variables are their own
+func (c *Compiler) declare(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, s := range node.Statements {
+			c.declare(s)
+		}
+	case *ast.BlockStatement:
+		if node == nil {
+			return
+		}
+		for _, s := range node.Statements {
+			c.declare(s)
+		}
+	case *ast.LetStatement:
+		c.symbolTable.Declare(node.Name.Value)
+		c.declare(node.Value)
+	case *ast.ReturnStatement:
+		c.declare(node.ReturnValue)
+	case *ast.ExpressionStatement:
+		c.declare(node.Expression)
+	case *ast.WhileStatement:
+		c.declare(node.Condition)
+		c.declare(node.Body)
+	case *ast.ForStatement:
+		c.declare(node.Init)
+		c.declare(node.Condition)
+		c.declare(node.Post)
+		c.declare(node.Body)
+	case *ast.ForInStatement:
+		c.symbolTable.Declare(node.Variable.Value)
+		c.declare(node.Iterable)
+		c.declare(node.Body)
+	case *ast.PrefixExpression:
+		c.declare(node.Right)
+	case *ast.PostfixExpression:
+		c.declare(node.Left)
+	case *ast.InfixExpression:
+		c.declare(node.Left)
+		c.declare(node.Right)
+	case *ast.LogicalExpression:
+		c.declare(node.Left)
+		c.declare(node.Right)
+	case *ast.AssignExpression:
+		c.declare(node.Target)
+		c.declare(node.Value)
+	case *ast.IfExpression:
+		c.declare(node.Condition)
+		c.declare(node.Consequence)
+		c.declare(node.Alternative)
+	case *ast.CallExpression:
+		c.declare(node.Function)
+		for _, arg := range node.Arguments {
+			c.declare(arg)
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			c.declare(el)
+		}
+	case *ast.IndexExpression:
+		c.declare(node.Left)
+		c.declare(node.Index)
+	case *ast.SliceExpression:
+		c.declare(node.Left)
+		c.declare(node.Low)
+		c.declare(node.High)
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			c.declare(pair.Key)
+			c.declare(pair.Value)
+		}
+	case *ast.InterpolatedString:
+		for _, exp := range node.Expressions {
+			c.declare(exp)
+		}
+	}
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{})
+	c.scopeIndex++
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() (code.Instructions, code.LineTable) {
+	scope := c.scopes[c.scopeIndex]
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+	c.symbolTable = c.symbolTable.Outer
+	return scope.instructions, scope.lines
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		Constants:    c.constants,
+		Lines:        c.scopes[c.scopeIndex].lines,
+		GlobalNames:  c.symbolTable.names(),
+	}
+}
+
+func (c *Compiler) errorf(node ast.Node, format string, a ...interface{}) error {
+	return &Error{Pos: node.Pos(), Msg: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,128 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope  SymbolScope = "GLOBAL"
+	LocalScope   SymbolScope = "LOCAL"
+	BuiltinScope SymbolScope = "BUILTIN"
+	FreeScope    SymbolScope = "FREE"
+)
+
+type Symbol struct {
+	Name     string
+	Scope    SymbolScope
+	Index    int
+	Constant bool // declared with sigma
+}
+
+// SymbolTable maps the names visible in one function, or in the whole
+// program for the outermost table, to their storage
+type SymbolTable struct {
+	Outer       *SymbolTable
+	FreeSymbols []Symbol // symbols of enclosing functions captured by this one
+
+	store          map[string]Symbol
+	numDefinitions int
+	declared       map[string]bool // names bound by a let anywhere in the function
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{store: make(map[string]Symbol)}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// Define binds name in this table. Redefining a variable of this table
+// reuses its slot.
+func (s *SymbolTable) Define(name string, constant bool) Symbol {
+	symbol, ok := s.store[name]
+	if !ok || (symbol.Scope != GlobalScope && symbol.Scope != LocalScope) {
+		symbol = Symbol{Name: name, Index: s.numDefinitions}
+		if s.Outer == nil {
+			symbol.Scope = GlobalScope
+		} else {
+			symbol.Scope = LocalScope
+		}
+		s.numDefinitions++
+	}
+	symbol.Constant = constant
+	s.store[name] = symbol
+	return symbol
+}
+
+// Declare records that a let further on in this table's function binds
+// name, so that code before it can refer to the variable
+func (s *SymbolTable) Declare(name string) {
+	if s.declared == nil {
+		s.declared = make(map[string]bool)
+	}
+	s.declared[name] = true
+}
+
+// Lookup returns the symbol defined for name in this table only
+func (s *SymbolTable) Lookup(name string) (Symbol, bool) {
+	symbol, ok := s.store[name]
+	return symbol, ok
+}
+
+func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
+	symbol := Symbol{Name: name, Index: index, Scope: BuiltinScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+	symbol := Symbol{Name: original.Name, Index: len(s.FreeSymbols) - 1, Scope: FreeScope, Constant: original.Constant}
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+// Resolve looks name up in this table and the enclosing ones. Locals of
+// enclosing functions become free symbols of this one, which the closure
+// shares with them. A name that is not defined yet but declared further on
+// is defined early in the innermost function declaring it, reading it before
+// the declaration ran is a runtime error.
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	if symbol, ok := s.resolve(name); ok {
+		return symbol, ok
+	}
+	for t := s; t != nil; t = t.Outer {
+		if t.declared[name] {
+			t.Define(name, false)
+			return s.resolve(name)
+		}
+	}
+	return Symbol{}, false
+}
+
+func (s *SymbolTable) resolve(name string) (Symbol, bool) {
+	symbol, ok := s.store[name]
+	if ok || s.Outer == nil {
+		return symbol, ok
+	}
+	symbol, ok = s.Outer.resolve(name)
+	if !ok {
+		return symbol, ok
+	}
+	if symbol.Scope == GlobalScope || symbol.Scope == BuiltinScope {
+		return symbol, ok
+	}
+	return s.defineFree(symbol), true
+}
+
+// names returns the names of the variables of this table by slot
+func (s *SymbolTable) names() []string {
+	names := make([]string, s.numDefinitions)
+	for name, symbol := range s.store {
+		if symbol.Scope == GlobalScope || symbol.Scope == LocalScope {
+			names[symbol.Index] = name
+		}
+	}
+	return names
+}
@@ -0,0 +1,792 @@
+package evaluator
+
+import (
+	"errors"
+	"math"
+	"skibidilang/ast"
+	"skibidilang/object"
+	"skibidilang/token"
+	"strings"
+)
+
+var (
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
+)
+
+// MaxCallDepth is the number of function calls that can be in progress at
+// once, as in the vm. Deeper recursion is reported as a stack overflow.
+const MaxCallDepth = 1024
+
+// Eval evaluates node in env. Runtime errors are returned as *object.Error
+// values carrying the position of the node that caused them.
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+	// Statements
+	case *ast.Program:
+		return evalProgram(node, env)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+	case *ast.LetStatement:
+		return evalLetStatement(node, env)
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{Value: NULL}
+		}
+		val := Eval(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.ForInStatement:
+		return evalForInStatement(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.PrefixExpression:
+		if node.Operator == "++" || node.Operator == "--" {
+			return evalIncDec(node, node.Operator, node.Right, true, env)
+		}
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node, node.Operator, right)
+	case *ast.PostfixExpression:
+		return evalIncDec(node, node.Operator, node.Left, false, env)
+	case *ast.InfixExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalInfixExpression(node, node.Operator, left, right)
+	case *ast.LogicalExpression:
+		return evalLogicalExpression(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.IfExpression:
+		return evalIfExpression(node, env)
+	case *ast.FunctionLiteral:
+		return &object.Function{Parameters: node.Parameters, Body: node.Body, Env: env}
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isError(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		return applyFunction(node, function, args, env)
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return evalIndexExpression(node, left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+	case *ast.BadExpression, *ast.BadStatement:
+		return newError(node, "cannot evaluate code with syntax errors")
+	}
+	if node == nil {
+		return NULL
+	}
+	return newError(node, "cannot evaluate %T", node)
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object = NULL
+	for _, statement := range program.Statements {
+		result = Eval(statement, env)
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+	return result
+}
+
+// evalBlockStatement stops at the first statement that unwinds: a goon,
+// yeet or mew, or an error. The enclosing function or loop handles it.
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object = NULL
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+		switch result.Type() {
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+			return result
+		}
+	}
+	return result
+}
+
+func evalLetStatement(node *ast.LetStatement, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	var err error
+	if node.IsConst() {
+		_, err = env.SetConst(node.Name.Value, val)
+	} else {
+		_, err = env.Set(node.Name.Value, val)
+	}
+	if err != nil {
+		return newError(node.Name, "cannot redeclare constant %s", node.Name.Value)
+	}
+	return NULL
+}
+
+func evalWhileStatement(node *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return NULL
+		}
+		if result, done := unwindLoop(Eval(node.Body, env)); done {
+			return result
+		}
+	}
+}
+
+func evalForStatement(node *ast.ForStatement, env *object.Environment) object.Object {
+	if node.Init != nil {
+		if init := Eval(node.Init, env); isError(init) {
+			return init
+		}
+	}
+	for {
+		if node.Condition != nil {
+			condition := Eval(node.Condition, env)
+			if isError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				return NULL
+			}
+		}
+		if result, done := unwindLoop(Eval(node.Body, env)); done {
+			return result
+		}
+		if node.Post != nil {
+			if post := Eval(node.Post, env); isError(post) {
+				return post
+			}
+		}
+	}
+}
+
+func evalForInStatement(node *ast.ForInStatement, env *object.Environment) object.Object {
+	iterable := Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+	var elements []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		// copied, so that the body may change the array it iterates over
+		elements = append(elements, iterable.Elements...)
+	case *object.String:
+		for _, r := range iterable.Value {
+			elements = append(elements, &object.String{Value: string(r)})
+		}
+	case *object.Hash:
+		for _, key := range iterable.Keys {
+			elements = append(elements, iterable.Pairs[key].Key)
+		}
+	default:
+		return newError(node.Iterable, "cannot iterate over %s", iterable.Type())
+	}
+	for _, element := range elements {
+		if _, err := env.Set(node.Variable.Value, element); err != nil {
+			return newError(node.Variable, "cannot assign to constant %s", node.Variable.Value)
+		}
+		if result, done := unwindLoop(Eval(node.Body, env)); done {
+			return result
+		}
+	}
+	return NULL
+}
+
+// unwindLoop reports whether the result of a loop body ends the loop, and
+// what the loop evaluates to then
+func unwindLoop(result object.Object) (object.Object, bool) {
+	switch result.(type) {
+	case *object.Break:
+		return NULL, true
+	case *object.ReturnValue, *object.Error:
+		return result, true
+	}
+	return nil, false
+}
+
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+	for i, literal := range node.Literals {
+		out.WriteString(literal.Value)
+		if i < len(node.Expressions) {
+			val := Eval(node.Expressions[i], env)
+			if isError(val) {
+				return val
+			}
+			out.WriteString(val.Inspect())
+		}
+	}
+	return &object.String{Value: out.String()}
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	if builtin := object.GetBuiltinByName(node.Value); builtin != nil {
+		return builtin
+	}
+	return newError(node, "identifier not found: %s", node.Value)
+}
+
+func evalPrefixExpression(node ast.Node, operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return nativeBoolToBooleanObject(!isTruthy(right))
+	case "-":
+		switch right := right.(type) {
+		case *object.Integer:
+			return &object.Integer{Value: -right.Value}
+		case *object.Float:
+			return &object.Float{Value: -right.Value}
+		}
+	case "~":
+		if right, ok := right.(*object.Integer); ok {
+			return &object.Integer{Value: ^right.Value}
+		}
+	}
+	return newError(node, "unknown operator: %s%s", operator, right.Type())
+}
+
+func evalInfixExpression(node ast.Node, operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(node, operator, left.(*object.Integer).Value, right.(*object.Integer).Value)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(node, operator, toFloat(left), toFloat(right))
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(node, operator, left.(*object.String).Value, right.(*object.String).Value)
+	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
+		l, r := left.(*object.Boolean).Value, right.(*object.Boolean).Value
+		switch operator {
+		case "==":
+			return nativeBoolToBooleanObject(l == r)
+		case "!=":
+			return nativeBoolToBooleanObject(l != r)
+		}
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	case left.Type() != right.Type():
+		return newError(node, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	}
+	return newError(node, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+func evalIntegerInfixExpression(node ast.Node, operator string, l, r int64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Integer{Value: l + r}
+	case "-":
+		return &object.Integer{Value: l - r}
+	case "*":
+		return &object.Integer{Value: l * r}
+	case "/", "%":
+		if r == 0 {
+			return newError(node, "division by zero")
+		}
+		if operator == "/" {
+			return &object.Integer{Value: l / r}
+		}
+		return &object.Integer{Value: l % r}
+	case "**":
+		if r < 0 {
+			return newError(node, "negative exponent %d for integer power", r)
+		}
+		return &object.Integer{Value: intPow(l, r)}
+	case "&":
+		return &object.Integer{Value: l & r}
+	case "|":
+		return &object.Integer{Value: l | r}
+	case "^":
+		return &object.Integer{Value: l ^ r}
+	case "&^":
+		return &object.Integer{Value: l &^ r}
+	case "<<", ">>":
+		if r < 0 {
+			return newError(node, "negative shift count %d", r)
+		}
+		if operator == "<<" {
+			return &object.Integer{Value: l << uint64(r)}
+		}
+		return &object.Integer{Value: l >> uint64(r)}
+	case "<":
+		return nativeBoolToBooleanObject(l < r)
+	case ">":
+		return nativeBoolToBooleanObject(l > r)
+	case "<=":
+		return nativeBoolToBooleanObject(l <= r)
+	case ">=":
+		return nativeBoolToBooleanObject(l >= r)
+	case "==":
+		return nativeBoolToBooleanObject(l == r)
+	case "!=":
+		return nativeBoolToBooleanObject(l != r)
+	}
+	return newError(node, "unknown operator: INTEGER %s INTEGER", operator)
+}
+
+// intPow computes base**exp by repeated squaring, wrapping around on
+// overflow like the other integer operators
+func intPow(base, exp int64) int64 {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exp >>= 1
+	}
+	return result
+}
+
+func evalFloatInfixExpression(node ast.Node, operator string, l, r float64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Float{Value: l + r}
+	case "-":
+		return &object.Float{Value: l - r}
+	case "*":
+		return &object.Float{Value: l * r}
+	case "/":
+		return &object.Float{Value: l / r}
+	case "%":
+		return &object.Float{Value: math.Mod(l, r)}
+	case "**":
+		return &object.Float{Value: math.Pow(l, r)}
+	case "<":
+		return nativeBoolToBooleanObject(l < r)
+	case ">":
+		return nativeBoolToBooleanObject(l > r)
+	case "<=":
+		return nativeBoolToBooleanObject(l <= r)
+	case ">=":
+		return nativeBoolToBooleanObject(l >= r)
+	case "==":
+		return nativeBoolToBooleanObject(l == r)
+	case "!=":
+		return nativeBoolToBooleanObject(l != r)
+	}
+	return newError(node, "unknown operator: FLOAT %s FLOAT", operator)
+}
+
+func evalStringInfixExpression(node ast.Node, operator string, l, r string) object.Object {
+	switch operator {
+	case "+":
+		return &object.String{Value: l + r}
+	case "<":
+		return nativeBoolToBooleanObject(l < r)
+	case ">":
+		return nativeBoolToBooleanObject(l > r)
+	case "<=":
+		return nativeBoolToBooleanObject(l <= r)
+	case ">=":
+		return nativeBoolToBooleanObject(l >= r)
+	case "==":
+		return nativeBoolToBooleanObject(l == r)
+	case "!=":
+		return nativeBoolToBooleanObject(l != r)
+	}
+	return newError(node, "unknown operator: STRING %s STRING", operator)
+}
+
+// evalLogicalExpression only evaluates the right operand when the left
+// one does not decide the result
+func evalLogicalExpression(node *ast.LogicalExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	if node.Operator == "&&" && !isTruthy(left) {
+		return FALSE
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return TRUE
+	}
+	right := Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	get, set, err := evalLocation(node.Target, env)
+	if err != nil {
+		return err
+	}
+	if node.Operator == "=" {
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return set(val)
+	}
+	// the current value is read before the right-hand side runs, as in
+	// compiled code
+	current := get()
+	if isError(current) {
+		return current
+	}
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	val = evalInfixExpression(node, strings.TrimSuffix(node.Operator, "="), current, val)
+	if isError(val) {
+		return val
+	}
+	return set(val)
+}
+
+// evalIncDec evaluates ++ and --, the prefix forms result in the new value
+// and the postfix forms in the old one
+func evalIncDec(node ast.Node, operator string, target ast.Expression, prefix bool, env *object.Environment) object.Object {
+	get, set, err := evalLocation(target, env)
+	if err != nil {
+		return err
+	}
+	current := get()
+	var updated object.Object
+	switch current := current.(type) {
+	case *object.Error:
+		return current
+	case *object.Integer:
+		if operator == "++" {
+			updated = &object.Integer{Value: current.Value + 1}
+		} else {
+			updated = &object.Integer{Value: current.Value - 1}
+		}
+	case *object.Float:
+		if operator == "++" {
+			updated = &object.Float{Value: current.Value + 1}
+		} else {
+			updated = &object.Float{Value: current.Value - 1}
+		}
+	default:
+		return newError(node, "invalid operand for %s: %s", operator, current.Type())
+	}
+	if result := set(updated); isError(result) {
+		return result
+	}
+	if prefix {
+		return updated
+	}
+	return current
+}
+
+// evalLocation evaluates the operands of an assignment target once and
+// returns functions reading and writing the location it denotes
+func evalLocation(target ast.Expression, env *object.Environment) (get func() object.Object, set func(object.Object) object.Object, err object.Object) {
+	switch target := target.(type) {
+	case *ast.Identifier:
+		get = func() object.Object { return evalIdentifier(target, env) }
+		set = func(val object.Object) object.Object {
+			_, err := env.Assign(target.Value, val)
+			switch {
+			case errors.Is(err, object.ErrConstant):
+				return newError(target, "cannot assign to constant %s", target.Value)
+			case err != nil:
+				return newError(target, "identifier not found: %s", target.Value)
+			}
+			return val
+		}
+		return get, set, nil
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return nil, nil, left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return nil, nil, index
+		}
+		get = func() object.Object { return evalIndexExpression(target, left, index) }
+		set = func(val object.Object) object.Object { return setIndex(target, left, index, val) }
+		return get, set, nil
+	}
+	return nil, nil, newError(target, "cannot assign to %s", target)
+}
+
+func evalIfExpression(node *ast.IfExpression, env *object.Environment) object.Object {
+	condition := Eval(node.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+	if isTruthy(condition) {
+		return Eval(node.Consequence, env)
+	}
+	if node.Alternative != nil {
+		return Eval(node.Alternative, env)
+	}
+	return NULL
+}
+
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+func applyFunction(node *ast.CallExpression, fn object.Object, args []object.Object, caller *object.Environment) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newError(node, "wrong number of arguments: want=%d, got=%d", len(fn.Parameters), len(args))
+		}
+		if caller.CallDepth() >= MaxCallDepth {
+			return newError(node, "stack overflow")
+		}
+		env := object.NewCallEnvironment(fn.Env, caller)
+		for i, parameter := range fn.Parameters {
+			env.Set(parameter.Value, args[i])
+		}
+		evaluated := Eval(fn.Body, env)
+		if returnValue, ok := evaluated.(*object.ReturnValue); ok {
+			return returnValue.Value
+		}
+		return evaluated
+	case *object.Builtin:
+		result := fn.Fn(args...)
+		if result == nil {
+			return NULL
+		}
+		if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+			// builtins do not know where they were called from
+			err.Pos = node.Pos()
+		}
+		return result
+	}
+	return newError(node, "not a function: %s", fn.Type())
+}
+
+func evalIndexExpression(node ast.Node, left, index object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := checkIndex(node, index, len(left.Elements))
+		if err != nil {
+			return err
+		}
+		return left.Elements[i]
+	case *object.String:
+		runes := []rune(left.Value)
+		i, err := checkIndex(node, index, len(runes))
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: string(runes[i])}
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError(node, "unusable as hash key: %s", index.Type())
+		}
+		if val, ok := left.Get(key); ok {
+			return val
+		}
+		return NULL
+	}
+	return newError(node, "index operator not supported: %s", left.Type())
+}
+
+func setIndex(node ast.Node, left, index, val object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := checkIndex(node, index, len(left.Elements))
+		if err != nil {
+			return err
+		}
+		left.Elements[i] = val
+		return val
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError(node, "unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, val)
+		return val
+	}
+	return newError(node, "index assignment not supported: %s", left.Type())
+}
+
+func checkIndex(node ast.Node, index object.Object, length int) (int, *object.Error) {
+	i, ok := index.(*object.Integer)
+	if !ok {
+		return 0, newError(node, "index must be INTEGER, got %s", index.Type())
+	}
+	if i.Value < 0 || i.Value >= int64(length) {
+		return 0, newError(node, "index out of range [%d] with length %d", i.Value, length)
+	}
+	return int(i.Value), nil
+}
+
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	var length int
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elements)
+	case *object.String:
+		length = len([]rune(left.Value))
+	default:
+		return newError(node, "slice operator not supported: %s", left.Type())
+	}
+	low, high := int64(0), int64(length)
+	for _, bound := range []struct {
+		exp   ast.Expression
+		value *int64
+	}{{node.Low, &low}, {node.High, &high}} {
+		if bound.exp == nil {
+			continue
+		}
+		val := Eval(bound.exp, env)
+		if isError(val) {
+			return val
+		}
+		i, ok := val.(*object.Integer)
+		if !ok {
+			return newError(bound.exp, "slice index must be INTEGER, got %s", val.Type())
+		}
+		*bound.value = i.Value
+	}
+	if low < 0 || high < low || high > int64(length) {
+		return newError(node, "slice bounds out of range [%d:%d] with length %d", low, high, length)
+	}
+	if array, ok := left.(*object.Array); ok {
+		elements := make([]object.Object, high-low)
+		copy(elements, array.Elements[low:high])
+		return &object.Array{Elements: elements}
+	}
+	return &object.String{Value: string([]rune(left.(*object.String).Value)[low:high])}
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError(pair.Key, "unusable as hash key: %s", key.Type())
+		}
+		value := Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+// isTruthy reports whether obj counts as true in a condition, only beta and
+// null count as false
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL, FALSE:
+		return false
+	}
+	return true
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
+
+func newError(node ast.Node, format string, a ...interface{}) *object.Error {
+	var pos token.Position
+	if node != nil {
+		pos = node.Pos()
+	}
+	return object.Errorf(pos, format, a...)
+}
@@ -0,0 +1,443 @@
+package evaluator
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"skibidilang/lexer"
+	"skibidilang/object"
+	"skibidilang/parser"
+	"testing"
+)
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"-10", -10},
+		{"-9223372036854775808", math.MinInt64},
+		{"-9223372036854775808 + 1", math.MinInt64 + 1},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"50 / 2 * 2 + 10", 60},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 % 3", 1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"6 &^ 3", 4},
+		{"1 << 4", 16},
+		{"-16 >> 2", -4},
+		{"~5", -6},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"-1.5", -1.5},
+		{"1.5 + 1", 2.5},
+		{"1 / 4.0", 0.25},
+		{"2.0 ** 0.5 * 2.0 ** 0.5", 2.0000000000000004},
+		{"7.5 % 2", 1.5},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		result, ok := evaluated.(*object.Float)
+		if !ok {
+			t.Errorf("%q: object is not Float. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if result.Value != tt.expected {
+			t.Errorf("%q: object has wrong value. got=%g, want=%g", tt.input, result.Value, tt.expected)
+		}
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"alpha", true},
+		{"beta", false},
+		{"1 < 2", true},
+		{"1 >= 2", false},
+		{"1 == 1.0", true},
+		{"1 != 1", false},
+		{"alpha == alpha", true},
+		{"alpha != beta", true},
+		{"(1 < 2) == alpha", true},
+		{`"a" < "b"`, true},
+		{`"a" == "a"`, true},
+		{`1 == "1"`, false},
+		{`1 != "1"`, true},
+		{"!alpha", false},
+		{"!!5", true},
+		{"alpha && beta", false},
+		{"beta || 5", true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestLogicalExpressionsShortCircuit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"skibidi x = 0; x != 0 && 10 / x > 2", false},
+		{"skibidi x = 0; x == 0 || 10 / x > 2", true},
+		{"skibidi calls = 0; skibidi f = ohio() { calls++; alpha }; beta && f(); calls == 0", true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (alpha) { 10 }", 10},
+		{"if (beta) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 > 2) { 10 } else if (2 > 1) { 30 } else { 20 }", 30},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if integer, ok := tt.expected.(int); ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"goon 10;", 10},
+		{"goon 10; 9;", 10},
+		{"9; goon 2 * 5; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { goon 10; } goon 1; }", 10},
+		{"skibidi f = ohio(x) { goon x; x + 10; }; f(10);", 10},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+	testNullObject(t, testEval(t, "skibidi f = ohio() { goon; 5 }; f()"))
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + alpha;", "1:1: type mismatch: INTEGER + BOOLEAN"},
+		{"-alpha", "1:1: unknown operator: -BOOLEAN"},
+		{"alpha + beta;", "1:1: unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; alpha + beta; 5", "1:4: unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) {\n  goon alpha + beta;\n}", "2:8: unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "1:1: identifier not found: foobar"},
+		{`"Hello" - "World"`, "1:1: unknown operator: STRING - STRING"},
+		{"10 / 0", "1:1: division by zero"},
+		{"2 ** -1", "1:1: negative exponent -1 for integer power"},
+		{"1 << -1", "1:1: negative shift count -1"},
+		{`{[1]: 2}`, "1:2: unusable as hash key: ARRAY"},
+		{"[1, 2][2]", "1:1: index out of range [2] with length 2"},
+		{"[1, 2, 3][2:1]", "1:1: slice bounds out of range [2:1] with length 3"},
+		{"5(1)", "1:1: not a function: INTEGER"},
+		{"ohio(x) { x }()", "1:1: wrong number of arguments: want=1, got=0"},
+		{`len(1)`, "1:1: argument to `len` not supported, got INTEGER"},
+		{"rizz x in 5 {}", "1:11: cannot iterate over INTEGER"},
+		{"x = 1", "1:1: identifier not found: x"},
+		{`skibidi s = "abc"; s[0] = "b"`, "1:20: index assignment not supported: STRING"},
+		{"skibidi f = ohio() { f() }; f()", "1:22: stack overflow"},
+		{"skibidi f = ohio(n) { f(n + 1) }; f(0)", "1:23: stack overflow"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Error() != tt.expected {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expected, errObj.Error())
+		}
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"skibidi a = 5; a;", 5},
+		{"skibidi a = 5 * 5; a;", 25},
+		{"skibidi a = 5; skibidi b = a; b;", 5},
+		{"skibidi a = 5; skibidi b = a; skibidi c = a + b + 5; c;", 15},
+		{"sigma a = 5; a * 2;", 10},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"skibidi a = 1; a = 2; a", 2},
+		{"skibidi a = 1; skibidi b = 1; a = b = 5; a + b", 10},
+		{"skibidi a = 10; a += 5; a -= 3; a *= 2; a /= 4; a %= 4; a", 2},
+		{"skibidi a = 6; a ^= 3", 5},
+		{"skibidi a = 1; a++; a++; a--; a", 2},
+		{"skibidi a = 1; a++", 1},
+		{"skibidi a = 1; ++a", 2},
+		{"skibidi xs = [1, 2, 3]; xs[1] = 5; xs[1] += 1; xs[1]", 6},
+		{"skibidi xs = [1, 2, 3]; xs[0]++; xs[0]", 2},
+		{`skibidi h = {"a": 1}; h["b"] = 2; h["a"] += 10; h["a"] + h["b"]`, 13},
+		{"skibidi n = 0; skibidi inc = ohio() { n += 1 }; inc(); inc(); n", 2},
+		{"skibidi n = 0; skibidi f = ohio(n) { n = 5 }; f(1); n", 0},
+		// the target is read before the right-hand side changes it
+		{"skibidi x = 1; x += x++; x", 2},
+		{"skibidi xs = [1]; xs[0] *= xs[0]++ + 1; xs[0]", 2},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestConstBindings(t *testing.T) {
+	tests := []string{
+		"sigma x = 1; skibidi f = ohio() { x }; x",
+		"sigma xs = [1]; xs[0] = 2; xs[0]",
+	}
+	for _, input := range tests {
+		if evaluated := testEval(t, input); isError(evaluated) {
+			t.Errorf("%q: unexpected error %s", input, evaluated.Inspect())
+		}
+	}
+	// the parser only sees one program at a time, bindings kept in the
+	// environment from an earlier program are checked when evaluating
+	changes := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 2;", "1:1: cannot assign to constant x"},
+		{"x *= 2;", "1:1: cannot assign to constant x"},
+		{"x++;", "1:1: cannot assign to constant x"},
+		{"skibidi x = 2;", "1:9: cannot redeclare constant x"},
+		{"rizz x in [1] {}", "1:6: cannot assign to constant x"},
+	}
+	env := object.NewEnvironment()
+	testEvalIn(t, "sigma x = 1;", env)
+	for _, tt := range changes {
+		evaluated := testEvalIn(t, tt.input, env)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Error() != tt.expected {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expected, errObj.Error())
+		}
+	}
+	testIntegerObject(t, testEvalIn(t, "x", env), 1)
+	// a function has its own scope, where the name can be bound again
+	testIntegerObject(t, testEvalIn(t, "ohio() { skibidi x = 5; x += 1 }()", env), 6)
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"skibidi i = 0; grind (i < 10) { i++ }; i", 10},
+		{"skibidi sum = 0; rizz (skibidi i = 1; i <= 10; i++) { sum += i }; sum", 55},
+		{"skibidi sum = 0; rizz (skibidi i = 0; i < 10; i++) { if (i % 2 == 0) { mew; } sum += i }; sum", 25},
+		{"skibidi i = 0; rizz (;;) { if (i == 7) { yeet; } i++ }; i", 7},
+		{"skibidi sum = 0; rizz x in [1, 2, 3] { sum += x }; sum", 6},
+		{`skibidi n = 0; rizz c in "héllo" { n++ }; n`, 5},
+		{`skibidi h = {"a": 1, "b": 2}; skibidi sum = 0; rizz k in h { sum += h[k] }; sum`, 3},
+		{"skibidi f = ohio() { rizz x in [1, 2, 3] { if (x == 2) { goon x * 10; } } 0 }; f()", 20},
+		{"skibidi n = 0; rizz (skibidi i = 0; i < 3; i++) { rizz (skibidi j = 0; j < 3; j++) { if (j == 1) { yeet; } n++ } }; n", 3},
+		{"skibidi n = 0; rizz x in [1, 2, 3, 4, 5] { if (x > 1) { if (x == 2) { mew } else if (x == 4) { yeet } } n += x }; n", 4},
+		{"skibidi n = 0; rizz x in [1, 2, 3] { n += if (x > 1) { rizz (;;) { yeet } x } else { 10 } }; n", 15},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"skibidi identity = ohio(x) { x; }; identity(5);", 5},
+		{"skibidi double = ohio(x) { x * 2; }; double(5);", 10},
+		{"skibidi add = ohio(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"ohio(x) { x; }(5)", 5},
+		{"skibidi newAdder = ohio(x) { ohio(y) { x + y } }; skibidi addTwo = newAdder(2); addTwo(3);", 5},
+		{"skibidi fib = ohio(n) { if (n < 2) { goon n; } fib(n - 1) + fib(n - 2) }; fib(15)", 610},
+		{"skibidi count = ohio(n) { if (n == 0) { goon 0; } 1 + count(n - 1) }; count(1000)", 1000},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"Hello World!"`, "Hello World!"},
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`skibidi name = "skibidi"; skibidi n = 2; "hi ${name}, ${n + 1} items"`, "hi skibidi, 3 items"},
+		{`"${[1, "a"]} ${alpha}"`, `[1, "a"] alpha`},
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[1:3]`, "él"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%q: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%q: String has wrong value. expected=%q, got=%q", tt.input, tt.expected, str.Value)
+		}
+	}
+}
+
+func TestArraysAndHashes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{"[1, 2, 3][0] + [1, 2, 3][2]", "4"},
+		{"skibidi xs = [1, 2, 3, 4]; xs[1:3]", "[2, 3]"},
+		{"skibidi xs = [1, 2, 3, 4]; xs[:2]", "[1, 2]"},
+		{"skibidi xs = [1, 2, 3, 4]; xs[2:]", "[3, 4]"},
+		{`{"one": 1, 2: "two", alpha: 3}`, `{"one": 1, 2: "two", alpha: 3}`},
+		{`{"a": 1}["a"]`, "1"},
+		{`{"a": 1}["b"]`, "null"},
+		{`len("héllo")`, "5"},
+		{`len([1, 2])`, "2"},
+		{`len({1: 2})`, "1"},
+		{`push([1], 2)`, "[1, 2]"},
+		{`first([1, 2])`, "1"},
+		{`last([1, 2])`, "2"},
+		{`rest([1, 2, 3])`, "[2, 3]"},
+		{`rest([])`, "null"},
+		{`skibidi xs = [1]; skibidi ys = push(xs, 2); len(xs)`, "1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPuts(t *testing.T) {
+	var out bytes.Buffer
+	object.Stdout = &out
+	defer func() { object.Stdout = os.Stdout }()
+	testNullObject(t, testEval(t, `puts("hello", 42, [1, "a"])`))
+	expected := "hello\n42\n[1, \"a\"]\n"
+	if out.String() != expected {
+		t.Errorf("wrong output. expected=%q, got=%q", expected, out.String())
+	}
+}
+
+func BenchmarkFib30(b *testing.B) {
+	input := `skibidi fib = ohio(n) { if (n < 2) { goon n; } fib(n - 1) + fib(n - 2) }; fib(30)`
+	program := parser.New(lexer.New(input)).ParseProgram()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if result := Eval(program, object.NewEnvironment()); isError(result) {
+			b.Fatalf("eval error: %s", result.Inspect())
+		}
+	}
+}
+
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
+	return testEvalIn(t, input, object.NewEnvironment())
+}
+
+func testEvalIn(t *testing.T, input string, env *object.Environment) object.Object {
+	t.Helper()
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser has errors: %q", input, p.Errors())
+	}
+	return Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	t.Helper()
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	t.Helper()
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	t.Helper()
+	if obj != NULL {
+		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
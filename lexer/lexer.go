@@ -1,36 +1,170 @@
 package lexer
 
 import (
+	"fmt"
 	"skibidilang/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error is a problem found while reading a token, such as an unterminated
+// string literal
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// Mode controls optional lexer behaviour
+type Mode uint
+
+const (
+	ScanComments Mode = 1 << iota // return comments as token.COMMENT instead of skipping them
 )
 
 type Lexer struct {
 	input        string
+	filename     string
 	position     int
 	nextPosition int
-	ch           byte
+	ch           rune // current character, 0 at EOF
+	line         int  // line of ch
+	column       int  // column of ch, counted in characters
+	errors       []Error
+	mode         Mode
+	templates    []template // interpolated strings whose expression is being read
+	unterminated bool       // a string literal ran into a newline or EOF
+}
+
+// template tracks an interpolated string while the tokens of one of its
+// embedded expressions are read
+type template struct {
+	start  token.Position // the opening quote
+	braces int            // unclosed { inside the embedded expression
 }
 
+// stringEnd tells how a part of a string literal ended
+type stringEnd int
+
+const (
+	stringClosed       stringEnd = iota // on the closing quote
+	stringInterpolated                  // on the { of ${
+	stringUnterminated                  // on a newline or EOF
+)
+
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewFile("", input)
+}
+
+// NewFile returns a lexer whose token positions carry the given filename
+func NewFile(filename, input string) *Lexer {
+	l := &Lexer{input: input, filename: filename, line: 1}
 	l.readChar()
+	if l.ch == bom {
+		l.readChar()
+		l.column = 1
+	}
 	return l
 }
 
+// SkipShebang skips a #! line at the start of the input, so that scripts
+// can be made executable. It does nothing once tokens have been read.
+func (l *Lexer) SkipShebang() {
+	if l.line != 1 || l.column != 1 || l.ch != '#' || l.peekChar() != '!' {
+		return
+	}
+	for l.ch != '\n' && !l.atEOF() {
+		l.readChar()
+	}
+}
+
+const bom = 0xFEFF // byte order mark, ignored at the start of the input
+
+// readChar advances to the next character, decoding UTF-8
 func (l *Lexer) readChar() {
+	if l.nextPosition > len(l.input) {
+		// already at EOF, keep the position stable
+		return
+	}
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.position = l.nextPosition
+	l.column++
 	if l.nextPosition >= len(l.input) {
 		l.ch = 0
-	} else {
-		l.ch = l.input[l.nextPosition]
+		l.nextPosition++
+		return
 	}
-	l.position = l.nextPosition
-	l.nextPosition += 1
+	r, width := utf8.DecodeRuneInString(l.input[l.nextPosition:])
+	if r == utf8.RuneError && width == 1 {
+		l.error(l.pos(), "invalid UTF-8 encoding (byte %#02x)", l.input[l.nextPosition])
+	}
+	l.ch = r
+	l.nextPosition += width
 }
 
-func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+// pos returns the position of the current character
+func (l *Lexer) pos() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
+// SetMode changes the mode used for the following tokens
+func (l *Lexer) SetMode(mode Mode) {
+	l.mode = mode
+}
+
+// Errors returns the errors found in all tokens read so far
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
+// OpenTemplate reports whether the input ended inside an embedded ${}
+// expression of a string literal. It is only meaningful once the EOF token
+// was returned.
+func (l *Lexer) OpenTemplate() bool {
+	return len(l.templates) > 0
+}
+
+// UnterminatedString reports whether a string literal read so far was
+// ended by a newline or EOF instead of its closing quote
+func (l *Lexer) UnterminatedString() bool {
+	return l.unterminated
+}
+
+func (l *Lexer) error(pos token.Position, format string, args ...interface{}) {
+	l.errors = append(l.errors, Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
 
+func (l *Lexer) NextToken() token.Token {
 	l.skipWhitespace()
+	for l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		start := l.pos()
+		comment := l.readComment()
+		if l.mode&ScanComments != 0 {
+			return token.Token{Type: token.COMMENT, Literal: comment, Pos: start, End: l.pos()}
+		}
+		l.skipWhitespace()
+	}
+	start := l.pos()
+	tok := l.scanToken()
+	tok.Pos = start
+	tok.End = l.pos()
+	return tok
+}
+
+func (l *Lexer) scanToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	//delimiters
@@ -38,6 +172,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = token.NewToken(token.SEMICOLON, l.ch)
 	case ',':
 		tok = token.NewToken(token.COMMA, l.ch)
+	case ':':
+		tok = token.NewToken(token.COLON, l.ch)
 		//operators
 	case '=':
 		if l.peekChar() == '=' {
@@ -50,6 +186,9 @@ func (l *Lexer) NextToken() token.Token {
 		if l.peekChar() == '+' {
 			l.readChar()
 			tok = token.NewTwoCharToken(token.INC, "++")
+		} else if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.ADD_ASSIGN, "+=")
 		} else {
 			tok = token.NewToken(token.ADD, l.ch)
 		}
@@ -57,6 +196,9 @@ func (l *Lexer) NextToken() token.Token {
 		if l.peekChar() == '-' {
 			l.readChar()
 			tok = token.NewTwoCharToken(token.DEC, "--")
+		} else if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.SUB_ASSIGN, "-=")
 		} else {
 			tok = token.NewToken(token.SUB, l.ch)
 		}
@@ -68,15 +210,38 @@ func (l *Lexer) NextToken() token.Token {
 			tok = token.NewToken(token.NOT, l.ch)
 		}
 	case '/':
-		tok = token.NewToken(token.SLASH, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.QUO_ASSIGN, "/=")
+		} else {
+			tok = token.NewToken(token.SLASH, l.ch)
+		}
 	case '*':
-		tok = token.NewToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.POWER, "**")
+		} else if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.MUL_ASSIGN, "*=")
+		} else {
+			tok = token.NewToken(token.ASTERISK, l.ch)
+		}
 	case '^':
-		tok = token.NewToken(token.POWER, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.XOR_ASSIGN, "^=")
+		} else {
+			tok = token.NewToken(token.XOR, l.ch)
+		}
+	case '~':
+		tok = token.NewToken(token.BITNOT, l.ch)
 	case '<':
 		if l.peekChar() == '=' {
 			l.readChar()
 			tok = token.NewTwoCharToken(token.LEQ, "<=")
+		} else if l.peekChar() == '<' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.SHL, "<<")
 		} else {
 			tok = token.NewToken(token.LT, l.ch)
 		}
@@ -84,41 +249,83 @@ func (l *Lexer) NextToken() token.Token {
 		if l.peekChar() == '=' {
 			l.readChar()
 			tok = token.NewTwoCharToken(token.GEQ, ">=")
+		} else if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.SHR, ">>")
 		} else {
 			tok = token.NewToken(token.GT, l.ch)
 		}
 	case '&':
-		tok = token.NewToken(token.AND, l.ch)
+		if l.peekChar() == '&' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.LAND, "&&")
+		} else if l.peekChar() == '^' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.AND_NOT, "&^")
+		} else {
+			tok = token.NewToken(token.AND, l.ch)
+		}
 	case '|':
-		tok = token.NewToken(token.OR, l.ch)
+		if l.peekChar() == '|' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.LOR, "||")
+		} else {
+			tok = token.NewToken(token.OR, l.ch)
+		}
 	case '%':
-		tok = token.NewToken(token.MOD, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.NewTwoCharToken(token.REM_ASSIGN, "%=")
+		} else {
+			tok = token.NewToken(token.MOD, l.ch)
+		}
 		//brackets
 	case '(':
 		tok = token.NewToken(token.LPAREN, l.ch)
 	case ')':
 		tok = token.NewToken(token.RPAREN, l.ch)
 	case '{':
+		if n := len(l.templates); n > 0 {
+			l.templates[n-1].braces++
+		}
 		tok = token.NewToken(token.LBRACE, l.ch)
 	case '}':
+		if n := len(l.templates); n > 0 {
+			if l.templates[n-1].braces == 0 {
+				// the embedded expression is complete, continue the string
+				t := l.templates[n-1]
+				l.templates = l.templates[:n-1]
+				return l.readStringToken(t.start, token.TEMPLATE_MIDDLE, token.TEMPLATE_TAIL)
+			}
+			l.templates[n-1].braces--
+		}
 		tok = token.NewToken(token.RBRACE, l.ch)
 	case '[':
 		tok = token.NewToken(token.LBRACK, l.ch)
 	case ']':
 		tok = token.NewToken(token.RBRACK, l.ch)
+	case '"':
+		return l.readStringToken(l.pos(), token.TEMPLATE_HEAD, token.STRING)
 	case 0:
-		tok.Literal = ""
-		tok.Type = token.EOF
+		if l.atEOF() {
+			tok.Literal = ""
+			tok.Type = token.EOF
+			break
+		}
+		l.error(l.pos(), "illegal character %q", l.ch)
+		tok = token.NewToken(token.ILLEGAL, l.ch)
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
-			return tok
+			return l.readNumber()
 		} else {
+			if l.ch != utf8.RuneError {
+				// invalid encodings are reported by readChar
+				l.error(l.pos(), "illegal character %q", l.ch)
+			}
 			tok = token.NewToken(token.ILLEGAL, l.ch)
 		}
 	}
@@ -126,40 +333,332 @@ func (l *Lexer) NextToken() token.Token {
 	return tok
 }
 
-func (l *Lexer) readIdentifier() string {
+// readComment reads a // line comment up to the end of the line, or a
+// /* block comment */, which may be nested. The returned text includes the
+// comment markers.
+func (l *Lexer) readComment() string {
 	position := l.position
-	for isLetter(l.ch) || isDigit(l.ch) {
+	start := l.pos()
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && !l.atEOF() {
+			l.readChar()
+		}
+		return l.input[position:l.position]
+	}
+	l.readChar()
+	l.readChar()
+	depth := 1
+	for depth > 0 {
+		switch {
+		case l.atEOF():
+			l.error(start, "unterminated block comment")
+			return l.input[position:l.position]
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isDigit(l.ch) {
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) || unicode.IsMark(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// readStringToken reads the part of a string literal following the current
+// character, which is its opening quote or the } closing an embedded
+// expression. The token has type interpolated if the part ends with ${
+// and closed if it ends the literal. start is the opening quote.
+func (l *Lexer) readStringToken(start token.Position, interpolated, closed token.TokenType) token.Token {
+	literal, end := l.readString(start)
+	switch end {
+	case stringInterpolated:
+		l.templates = append(l.templates, template{start: start})
+		l.readChar()
+		return token.Token{Type: interpolated, Literal: literal}
+	case stringClosed:
+		l.readChar()
+		return token.Token{Type: closed, Literal: literal}
+	default:
+		// leave the newline or EOF that ended the literal
+		return token.Token{Type: token.ILLEGAL, Literal: literal}
+	}
+}
+
+// readString reads the characters of a string literal following the
+// current one and returns their value with escape sequences decoded. It
+// leaves the lexer on the closing quote, on the { of an embedded ${
+// expression, or on the newline or EOF that ended an unterminated literal.
+func (l *Lexer) readString(start token.Position) (value string, end stringEnd) {
+	var out strings.Builder
+	for {
+		l.readChar()
+		switch {
+		case l.ch == '"':
+			return out.String(), stringClosed
+		case l.ch == '$' && l.peekChar() == '{':
+			l.readChar()
+			return out.String(), stringInterpolated
+		case l.ch == '\n' || l.atEOF():
+			l.unterminated = true
+			l.error(start, "unterminated string literal")
+			return out.String(), stringUnterminated
+		case l.ch == '\\':
+			l.readEscape(&out)
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readEscape decodes the escape sequence starting at the backslash under
+// the cursor, leaving the lexer on its last character. Invalid sequences
+// are reported and dropped from the value.
+func (l *Lexer) readEscape(out *strings.Builder) {
+	start := l.pos()
+	switch l.peekChar() {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case '$':
+		out.WriteByte('$')
+	case 'u':
+		l.readChar()
+		l.readUnicodeEscape(start, out)
+		return
+	case '\n', 0:
+		// leave the end of the line to readString
+		return
+	default:
+		l.error(start, "unknown escape sequence \\%c", l.peekChar())
+	}
+	l.readChar()
+}
+
+// readUnicodeEscape decodes \u{X} where X is 1 to 6 hex digits naming a
+// valid code point
+func (l *Lexer) readUnicodeEscape(start token.Position, out *strings.Builder) {
+	if l.peekChar() != '{' {
+		l.error(start, "invalid unicode escape: expected { after \\u")
+		return
+	}
+	l.readChar()
+	var code rune
+	digits := 0
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+		code = code*16 + rune(hexValue(l.ch))
+		digits++
+		if digits > 6 {
+			break
+		}
+	}
+	if l.peekChar() != '}' || digits == 0 || digits > 6 {
+		l.error(start, "invalid unicode escape: expected 1 to 6 hex digits in \\u{...}")
+		for isHexDigit(l.peekChar()) {
+			l.readChar()
+		}
+		if l.peekChar() == '}' {
+			l.readChar()
+		}
+		return
+	}
+	l.readChar()
+	if !utf8.ValidRune(code) {
+		l.error(start, "invalid unicode escape: %U is not a valid code point", code)
+		return
+	}
+	out.WriteRune(code)
 }
 
-func isDigit(ch byte) bool {
+// readNumber reads an integer or floating-point literal. Integers may have
+// a 0x, 0o or 0b prefix, and digits may be separated by underscores.
+// Malformed literals are reported at their start and returned as ILLEGAL.
+func (l *Lexer) readNumber() token.Token {
+	position := l.position
+	start := l.pos()
+	tokenType := token.TokenType(token.INT)
+	base := 10
+	if l.ch == '0' {
+		switch l.peekChar() {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			l.readChar()
+			l.readChar()
+		}
+	}
+	var msg string
+	if n, invalid := l.readDigits(base); n == 0 {
+		msg = fmt.Sprintf("%s literal has no digits", baseName(base))
+	} else if invalid != 0 {
+		msg = fmt.Sprintf("invalid digit %q in %s literal", invalid, baseName(base))
+	}
+	if base == 10 && msg == "" {
+		if l.ch == '.' && isDigit(l.peekChar()) {
+			tokenType = token.FLOAT
+			l.readChar()
+			l.readDigits(10)
+		}
+		if l.ch == 'e' || l.ch == 'E' {
+			tokenType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			if n, _ := l.readDigits(10); n == 0 {
+				msg = "exponent has no digits"
+			}
+		}
+	}
+	if msg == "" && (isLetter(l.ch) || isDigit(l.ch)) {
+		msg = fmt.Sprintf("invalid character %q in numeric literal", l.ch)
+	}
+	if msg != "" {
+		// skip the rest of the malformed literal
+		for isLetter(l.ch) || isDigit(l.ch) {
+			l.readChar()
+		}
+	}
+	literal := l.input[position:l.position]
+	if msg == "" && !validSeparators(literal, base) {
+		msg = "'_' must separate successive digits"
+	}
+	if msg == "" && tokenType == token.INT && base == 10 && literal[0] == '0' {
+		// a leading zero makes an octal literal, as in Go
+		for i := 1; i < len(literal); i++ {
+			if literal[i] == '8' || literal[i] == '9' {
+				msg = fmt.Sprintf("invalid digit %q in octal literal", literal[i])
+				break
+			}
+		}
+	}
+	if msg != "" {
+		l.error(start, "%s", msg)
+		return token.Token{Type: token.ILLEGAL, Literal: literal}
+	}
+	return token.Token{Type: tokenType, Literal: literal}
+}
+
+// readDigits reads digits and underscores. It returns the number of digits
+// and the first one that is not valid in base, if any. For bases up to 10
+// every decimal digit is read so the error can point at it.
+func (l *Lexer) readDigits(base int) (n int, invalid rune) {
+	for {
+		switch {
+		case l.ch == '_':
+		case base == 16 && isHexDigit(l.ch):
+			n++
+		case base <= 10 && isDigit(l.ch):
+			if int(l.ch-'0') >= base && invalid == 0 {
+				invalid = l.ch
+			}
+			n++
+		default:
+			return n, invalid
+		}
+		l.readChar()
+	}
+}
+
+// validSeparators reports whether every underscore in a numeric literal
+// sits between two digits, or directly after a base prefix
+func validSeparators(literal string, base int) bool {
+	isBaseDigit := isDigit
+	if base == 16 {
+		isBaseDigit = isHexDigit
+	}
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '_' {
+			continue
+		}
+		prefix := i == 2 && base != 10
+		if !prefix && !isBaseDigit(rune(literal[i-1])) {
+			return false
+		}
+		if i+1 >= len(literal) || !isBaseDigit(rune(literal[i+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func baseName(base int) string {
+	switch base {
+	case 16:
+		return "hexadecimal"
+	case 8:
+		return "octal"
+	case 2:
+		return "binary"
+	}
+	return "decimal"
+}
+
+// isLetter reports whether ch can start an identifier. Identifiers may
+// additionally contain Unicode digits and combining marks.
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
+// isDigit reports whether ch is an ASCII digit, the only digits allowed in
+// numeric literals
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func hexValue(ch rune) int {
+	switch {
+	case isDigit(ch):
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	default:
+		return int(ch-'A') + 10
+	}
+}
+
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		l.readChar()
 	}
 }
 
-func (l *Lexer) peekChar() byte {
+// atEOF reports whether the whole input has been read
+func (l *Lexer) atEOF() bool {
+	return l.position >= len(l.input)
+}
+
+func (l *Lexer) peekChar() rune {
 	if l.nextPosition >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.nextPosition]
+		r, _ := utf8.DecodeRuneInString(l.input[l.nextPosition:])
+		return r
 	}
 }
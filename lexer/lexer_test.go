@@ -13,10 +13,10 @@ skibidi add = ohio(x, y) {
 x + y;
 };
 skibidi result1 = add(five1, ten_);
-!-/*5^;
+!-/ *5**;
 5 < 10 > 5;
 
-&|%[]
+&|%[]:
 if (5 < 10) {
 	goon alpha;
 } else {
@@ -28,6 +28,11 @@ if (5 < 10) {
 <=
 ++
 --
+&& ||
+^ ~ << >> &^ * ** &
++= -= *= /= %= ^=
+grind rizz in yeet mew
+sigma
 `
 	tests := []struct {
 		expectedType    token.TokenType
@@ -74,7 +79,7 @@ if (5 < 10) {
 		{token.SLASH, "/"},
 		{token.ASTERISK, "*"},
 		{token.INT, "5"},
-		{token.POWER, "^"},
+		{token.POWER, "**"},
 		{token.SEMICOLON, ";"},
 		{token.INT, "5"},
 		{token.LT, "<"},
@@ -87,6 +92,7 @@ if (5 < 10) {
 		{token.MOD, "%"},
 		{token.LBRACK, "["},
 		{token.RBRACK, "]"},
+		{token.COLON, ":"},
 		{token.IF, "if"},
 		{token.LPAREN, "("},
 		{token.INT, "5"},
@@ -116,6 +122,28 @@ if (5 < 10) {
 		{token.LEQ, "<="},
 		{token.INC, "++"},
 		{token.DEC, "--"},
+		{token.LAND, "&&"},
+		{token.LOR, "||"},
+		{token.XOR, "^"},
+		{token.BITNOT, "~"},
+		{token.SHL, "<<"},
+		{token.SHR, ">>"},
+		{token.AND_NOT, "&^"},
+		{token.ASTERISK, "*"},
+		{token.POWER, "**"},
+		{token.AND, "&"},
+		{token.ADD_ASSIGN, "+="},
+		{token.SUB_ASSIGN, "-="},
+		{token.MUL_ASSIGN, "*="},
+		{token.QUO_ASSIGN, "/="},
+		{token.REM_ASSIGN, "%="},
+		{token.XOR_ASSIGN, "^="},
+		{token.WHILE, "grind"},
+		{token.FOR, "rizz"},
+		{token.IN, "in"},
+		{token.BREAK, "yeet"},
+		{token.CONTINUE, "mew"},
+		{token.CONST, "sigma"},
 		{token.EOF, ""},
 	}
 	l := New(input)
@@ -132,3 +160,422 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "skibidi x = 10;\n  goon x >= 5;\n"
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+		expectedEnd  token.Position
+	}{
+		{token.LET, token.Position{Filename: "main.skb", Offset: 0, Line: 1, Column: 1}, token.Position{Filename: "main.skb", Offset: 7, Line: 1, Column: 8}},
+		{token.IDENT, token.Position{Filename: "main.skb", Offset: 8, Line: 1, Column: 9}, token.Position{Filename: "main.skb", Offset: 9, Line: 1, Column: 10}},
+		{token.ASSIGN, token.Position{Filename: "main.skb", Offset: 10, Line: 1, Column: 11}, token.Position{Filename: "main.skb", Offset: 11, Line: 1, Column: 12}},
+		{token.INT, token.Position{Filename: "main.skb", Offset: 12, Line: 1, Column: 13}, token.Position{Filename: "main.skb", Offset: 14, Line: 1, Column: 15}},
+		{token.SEMICOLON, token.Position{Filename: "main.skb", Offset: 14, Line: 1, Column: 15}, token.Position{Filename: "main.skb", Offset: 15, Line: 1, Column: 16}},
+		{token.RETURN, token.Position{Filename: "main.skb", Offset: 18, Line: 2, Column: 3}, token.Position{Filename: "main.skb", Offset: 22, Line: 2, Column: 7}},
+		{token.IDENT, token.Position{Filename: "main.skb", Offset: 23, Line: 2, Column: 8}, token.Position{Filename: "main.skb", Offset: 24, Line: 2, Column: 9}},
+		{token.GEQ, token.Position{Filename: "main.skb", Offset: 25, Line: 2, Column: 10}, token.Position{Filename: "main.skb", Offset: 27, Line: 2, Column: 12}},
+		{token.INT, token.Position{Filename: "main.skb", Offset: 28, Line: 2, Column: 13}, token.Position{Filename: "main.skb", Offset: 29, Line: 2, Column: 14}},
+		{token.SEMICOLON, token.Position{Filename: "main.skb", Offset: 29, Line: 2, Column: 14}, token.Position{Filename: "main.skb", Offset: 30, Line: 2, Column: 15}},
+		{token.EOF, token.Position{Filename: "main.skb", Offset: 31, Line: 3, Column: 1}, token.Position{Filename: "main.skb", Offset: 31, Line: 3, Column: 1}},
+		{token.EOF, token.Position{Filename: "main.skb", Offset: 31, Line: 3, Column: 1}, token.Position{Filename: "main.skb", Offset: 31, Line: 3, Column: 1}},
+	}
+	l := NewFile("main.skb", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - pos wrong. expected=%+v, got=%+v",
+				i, tt.expectedPos, tok.Pos)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - end wrong. expected=%+v, got=%+v",
+				i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedErrors  []string
+	}{
+		{`"foobar"`, token.STRING, "foobar", nil},
+		{`"foo bar"`, token.STRING, "foo bar", nil},
+		{`""`, token.STRING, "", nil},
+		{`"a\nb\tc"`, token.STRING, "a\nb\tc", nil},
+		{`"say \"hi\" \\ bye"`, token.STRING, `say "hi" \ bye`, nil},
+		{`"caf\u{e9} \u{1F600}"`, token.STRING, "café 😀", nil},
+		{`"a\qb"`, token.STRING, "ab", []string{`1:3: unknown escape sequence \q`}},
+		{`"\u{}"`, token.STRING, "", []string{`1:2: invalid unicode escape: expected 1 to 6 hex digits in \u{...}`}},
+		{`"\u{1234567}x"`, token.STRING, "x", []string{`1:2: invalid unicode escape: expected 1 to 6 hex digits in \u{...}`}},
+		{`"\u{D800}"`, token.STRING, "", []string{`1:2: invalid unicode escape: U+D800 is not a valid code point`}},
+		{`"\u41"`, token.STRING, "41", []string{`1:2: invalid unicode escape: expected { after \u`}},
+		{`"abc`, token.ILLEGAL, "abc", []string{"1:1: unterminated string literal"}},
+		{"\"abc\ndef\"", token.ILLEGAL, "abc", []string{"1:1: unterminated string literal"}},
+		{`"abc\`, token.ILLEGAL, "abc", []string{"1:1: unterminated string literal"}},
+	}
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Errorf("%q: tokentype wrong. expected=%q, got=%q", tt.input, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("%q: literal wrong. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+		errs := l.Errors()
+		if len(errs) != len(tt.expectedErrors) {
+			t.Errorf("%q: expected %d errors, got %d: %v", tt.input, len(tt.expectedErrors), len(errs), errs)
+			continue
+		}
+		for i, msg := range tt.expectedErrors {
+			if errs[i].Error() != msg {
+				t.Errorf("%q: error wrong. expected=%q, got=%q", tt.input, msg, errs[i].Error())
+			}
+		}
+	}
+}
+
+func TestUnterminatedStringRecovery(t *testing.T) {
+	l := New("skibidi s = \"abc\nskibidi t = 1;")
+	expected := []token.TokenType{
+		token.LET, token.IDENT, token.ASSIGN, token.ILLEGAL,
+		token.LET, token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON, token.EOF,
+	}
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	input := `"hello ${name}, you have ${count + 1} items" "${ {a: "x${y}z"}[a] }" "\${no}" "$5"`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TEMPLATE_HEAD, "hello "},
+		{token.IDENT, "name"},
+		{token.TEMPLATE_MIDDLE, ", you have "},
+		{token.IDENT, "count"},
+		{token.ADD, "+"},
+		{token.INT, "1"},
+		{token.TEMPLATE_TAIL, " items"},
+		{token.TEMPLATE_HEAD, ""},
+		{token.LBRACE, "{"},
+		{token.IDENT, "a"},
+		{token.COLON, ":"},
+		{token.TEMPLATE_HEAD, "x"},
+		{token.IDENT, "y"},
+		{token.TEMPLATE_TAIL, "z"},
+		{token.RBRACE, "}"},
+		{token.LBRACK, "["},
+		{token.IDENT, "a"},
+		{token.RBRACK, "]"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.STRING, "${no}"},
+		{token.STRING, "$5"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestNumericLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedError   string
+	}{
+		{"42", token.INT, "42", ""},
+		{"0", token.INT, "0", ""},
+		{"0xFF", token.INT, "0xFF", ""},
+		{"0Xff_ff", token.INT, "0Xff_ff", ""},
+		{"0o17", token.INT, "0o17", ""},
+		{"017", token.INT, "017", ""},
+		{"0b1010", token.INT, "0b1010", ""},
+		{"0b_1010", token.INT, "0b_1010", ""},
+		{"1_000_000", token.INT, "1_000_000", ""},
+		{"3.14", token.FLOAT, "3.14", ""},
+		{"1e-9", token.FLOAT, "1e-9", ""},
+		{"6.022E+23", token.FLOAT, "6.022E+23", ""},
+		{"1_000.000_1", token.FLOAT, "1_000.000_1", ""},
+		{"2e10", token.FLOAT, "2e10", ""},
+		{"0x", token.ILLEGAL, "0x", "1:1: hexadecimal literal has no digits"},
+		{"0b102", token.ILLEGAL, "0b102", "1:1: invalid digit '2' in binary literal"},
+		{"0o78", token.ILLEGAL, "0o78", "1:1: invalid digit '8' in octal literal"},
+		{"09", token.ILLEGAL, "09", "1:1: invalid digit '9' in octal literal"},
+		{"1e", token.ILLEGAL, "1e", "1:1: exponent has no digits"},
+		{"1e+x", token.ILLEGAL, "1e+x", "1:1: exponent has no digits"},
+		{"12abc", token.ILLEGAL, "12abc", "1:1: invalid character 'a' in numeric literal"},
+		{"1__0", token.ILLEGAL, "1__0", "1:1: '_' must separate successive digits"},
+		{"10_", token.ILLEGAL, "10_", "1:1: '_' must separate successive digits"},
+		{"1_.5", token.ILLEGAL, "1_.5", "1:1: '_' must separate successive digits"},
+	}
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Errorf("%q: tokentype wrong. expected=%q, got=%q", tt.input, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("%q: literal wrong. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+		errs := l.Errors()
+		if tt.expectedError == "" {
+			if len(errs) != 0 {
+				t.Errorf("%q: unexpected errors: %v", tt.input, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 || errs[0].Error() != tt.expectedError {
+			t.Errorf("%q: expected error %q, got %v", tt.input, tt.expectedError, errs)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+skibidi x = 10 / 2; // trailing
+/* block /* nested */ still comment */ x
+/**/y// end`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// leading comment"},
+		{token.LET, "skibidi"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "10"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.COMMENT, "// trailing"},
+		{token.COMMENT, "/* block /* nested */ still comment */"},
+		{token.IDENT, "x"},
+		{token.COMMENT, "/**/"},
+		{token.IDENT, "y"},
+		{token.COMMENT, "// end"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	l.SetMode(ScanComments)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	// without ScanComments the comments are skipped
+	l = New(input)
+	for i, tt := range tests {
+		if tt.expectedType == token.COMMENT {
+			continue
+		}
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	l := New("x /* open /* nested */ still open")
+	if tok := l.NextToken(); tok.Type != token.IDENT {
+		t.Fatalf("tokentype wrong. expected=%q, got=%q", token.IDENT, tok.Type)
+	}
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("tokentype wrong. expected=%q, got=%q", token.EOF, tok.Type)
+	}
+	errs := l.Errors()
+	if len(errs) != 1 || errs[0].Error() != "1:3: unterminated block comment" {
+		t.Errorf("wrong errors: %v", errs)
+	}
+}
+
+func TestOpenTemplate(t *testing.T) {
+	tests := []struct {
+		input        string
+		open         bool
+		unterminated bool
+	}{
+		{`"abc"`, false, false},
+		{`"abc`, false, true},
+		{"\"abc\nx", false, true},
+		{`"a ${b`, true, false},
+		{"\"a ${b +\nc", true, false},
+		{`"a ${ {"b": 1}["b"] } c"`, false, false},
+		{`"a ${b} c`, false, true},
+		{`"a ${ "b`, true, true},
+	}
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+		if l.OpenTemplate() != tt.open {
+			t.Errorf("%q: OpenTemplate() = %t, want %t", tt.input, l.OpenTemplate(), tt.open)
+		}
+		if l.UnterminatedString() != tt.unterminated {
+			t.Errorf("%q: UnterminatedString() = %t, want %t", tt.input, l.UnterminatedString(), tt.unterminated)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := "skibidi café = 变量 + Δx_2 + naïve + ß٣;\n\"héllo wörld\" ≠"
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.LET, "skibidi", 1},
+		{token.IDENT, "café", 9},
+		{token.ASSIGN, "=", 14},
+		{token.IDENT, "变量", 16},
+		{token.ADD, "+", 19},
+		{token.IDENT, "Δx_2", 21},
+		{token.ADD, "+", 26},
+		{token.IDENT, "naïve", 28},
+		{token.ADD, "+", 34},
+		{token.IDENT, "ß٣", 36},
+		{token.SEMICOLON, ";", 38},
+		{token.STRING, "héllo wörld", 1},
+		{token.ILLEGAL, "≠", 15},
+		{token.EOF, "", 16},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - column wrong. expected=%d, got=%d",
+				i, tt.expectedColumn, tok.Pos.Column)
+		}
+	}
+	errs := l.Errors()
+	if len(errs) != 1 || errs[0].Error() != "2:15: illegal character '≠'" {
+		t.Errorf("wrong errors: %v", errs)
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	input := "x = \xff;\n\"a\xc3b\""
+	l := New(input)
+	expected := []token.TokenType{token.IDENT, token.ASSIGN, token.ILLEGAL, token.SEMICOLON, token.STRING, token.EOF}
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+	errs := l.Errors()
+	expectedErrors := []string{
+		"1:5: invalid UTF-8 encoding (byte 0xff)",
+		"2:3: invalid UTF-8 encoding (byte 0xc3)",
+	}
+	if len(errs) != len(expectedErrors) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expectedErrors), len(errs), errs)
+	}
+	for i, msg := range expectedErrors {
+		if errs[i].Error() != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, errs[i].Error())
+		}
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	l := New("\uFEFFskibidi")
+	tok := l.NextToken()
+	if tok.Type != token.LET {
+		t.Fatalf("tokentype wrong. expected=%q, got=%q", token.LET, tok.Type)
+	}
+	if tok.Pos.Column != 1 || tok.Pos.Offset != 3 {
+		t.Errorf("pos wrong. got=%+v", tok.Pos)
+	}
+}
+
+func TestShebangLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.TokenType
+		pos      string // of the first token
+	}{
+		{"#!/usr/bin/env skibidi\nskibidi x", []token.TokenType{token.LET, token.IDENT, token.EOF}, "2:1"},
+		{"\uFEFF#!/usr/bin/env skibidi\r\n x", []token.TokenType{token.IDENT, token.EOF}, "2:2"},
+		{"#!skibidi", []token.TokenType{token.EOF}, "1:10"},
+		{"x\n#!skibidi", []token.TokenType{token.IDENT, token.ILLEGAL, token.NOT, token.LET, token.EOF}, "1:1"},
+	}
+	for _, tt := range tests {
+		l := New(tt.input)
+		l.SkipShebang()
+		for i, expected := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != expected {
+				t.Fatalf("%q: tests[%d] - tokentype wrong. expected=%q, got=%q", tt.input, i, expected, tok.Type)
+			}
+			if i == 0 && tok.Pos.String() != tt.pos {
+				t.Errorf("%q: pos wrong. expected=%s, got=%s", tt.input, tt.pos, tok.Pos)
+			}
+		}
+	}
+}
+
+func TestShebangLineNotSkippedByDefault(t *testing.T) {
+	l := New("#!skibidi")
+	expected := []token.TokenType{token.ILLEGAL, token.NOT, token.LET, token.EOF}
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+
+	// too late once a token was read
+	l = New("#!skibidi")
+	l.NextToken()
+	l.SkipShebang()
+	if tok := l.NextToken(); tok.Type != token.NOT {
+		t.Errorf("SkipShebang after the first token skipped input, got=%q", tok.Type)
+	}
+}
@@ -0,0 +1,126 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// Stdout is where puts writes to
+var Stdout io.Writer = os.Stdout
+
+// Builtins lists the functions provided by the interpreter. Compiled code
+// refers to them by index, so new ones must only be appended. A builtin
+// returns nil for null.
+var Builtins = []*Builtin{
+	{Name: "len", Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return wrongArgumentCount(1, len(args))
+		}
+		switch arg := args[0].(type) {
+		case *String:
+			return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+		case *Array:
+			return &Integer{Value: int64(len(arg.Elements))}
+		case *Hash:
+			return &Integer{Value: int64(len(arg.Keys))}
+		}
+		return unsupportedArgument("len", args[0])
+	}},
+	{Name: "puts", Fn: func(args ...Object) Object {
+		for _, arg := range args {
+			fmt.Fprintln(Stdout, arg.Inspect())
+		}
+		return nil
+	}},
+	{Name: "push", Fn: func(args ...Object) Object {
+		if len(args) != 2 {
+			return wrongArgumentCount(2, len(args))
+		}
+		array, ok := args[0].(*Array)
+		if !ok {
+			return unsupportedArgument("push", args[0])
+		}
+		elements := make([]Object, len(array.Elements), len(array.Elements)+1)
+		copy(elements, array.Elements)
+		return &Array{Elements: append(elements, args[1])}
+	}},
+	{Name: "first", Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return wrongArgumentCount(1, len(args))
+		}
+		array, ok := args[0].(*Array)
+		if !ok {
+			return unsupportedArgument("first", args[0])
+		}
+		if len(array.Elements) == 0 {
+			return nil
+		}
+		return array.Elements[0]
+	}},
+	{Name: "last", Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return wrongArgumentCount(1, len(args))
+		}
+		array, ok := args[0].(*Array)
+		if !ok {
+			return unsupportedArgument("last", args[0])
+		}
+		if len(array.Elements) == 0 {
+			return nil
+		}
+		return array.Elements[len(array.Elements)-1]
+	}},
+	{Name: "rest", Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return wrongArgumentCount(1, len(args))
+		}
+		array, ok := args[0].(*Array)
+		if !ok {
+			return unsupportedArgument("rest", args[0])
+		}
+		if len(array.Elements) == 0 {
+			return nil
+		}
+		elements := make([]Object, len(array.Elements)-1)
+		copy(elements, array.Elements[1:])
+		return &Array{Elements: elements}
+	}},
+	{Name: "exit", Fn: func(args ...Object) Object {
+		if len(args) > 1 {
+			return wrongArgumentCount(1, len(args))
+		}
+		code := 0
+		if len(args) == 1 {
+			status, ok := args[0].(*Integer)
+			if !ok {
+				return unsupportedArgument("exit", args[0])
+			}
+			if status.Value < 0 || status.Value > 255 {
+				return &Error{Message: fmt.Sprintf("exit status must be between 0 and 255, got %d", status.Value)}
+			}
+			code = int(status.Value)
+		}
+		// unwinds like an error, the caller ends the program
+		return &Error{Message: fmt.Sprintf("exit(%d)", code), Exit: true, Status: code}
+	}},
+}
+
+// GetBuiltinByName returns the builtin called name, or nil
+func GetBuiltinByName(name string) *Builtin {
+	for _, builtin := range Builtins {
+		if builtin.Name == name {
+			return builtin
+		}
+	}
+	return nil
+}
+
+func wrongArgumentCount(want, got int) *Error {
+	return &Error{Message: fmt.Sprintf("wrong number of arguments: want=%d, got=%d", want, got)}
+}
+
+func unsupportedArgument(name string, arg Object) *Error {
+	return &Error{Message: fmt.Sprintf("argument to `%s` not supported, got %s", name, arg.Type())}
+}
@@ -0,0 +1,94 @@
+package object
+
+import "errors"
+
+var (
+	// ErrUndefined is returned when assigning to a name that was never bound
+	ErrUndefined = errors.New("identifier not found")
+	// ErrConstant is returned when assigning to or redeclaring a constant
+	ErrConstant = errors.New("constant binding")
+)
+
+type binding struct {
+	value    Object
+	constant bool
+}
+
+// Environment holds the bindings of one function call, or of the whole
+// program for the outermost one
+type Environment struct {
+	store map[string]binding
+	outer *Environment
+	depth int // number of function calls in progress
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]binding)}
+}
+
+// NewEnclosedEnvironment returns an environment whose lookups fall back
+// to outer
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// NewCallEnvironment returns the environment of a call made from caller to
+// a function defined in outer
+func NewCallEnvironment(outer, caller *Environment) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.depth = caller.depth + 1
+	return env
+}
+
+// CallDepth returns the number of function calls in progress, the
+// outermost environment has none
+func (e *Environment) CallDepth() int {
+	return e.depth
+}
+
+// Get looks name up in the environment and its outer environments
+func (e *Environment) Get(name string) (Object, bool) {
+	b, ok := e.store[name]
+	if !ok && e.outer != nil {
+		return e.outer.Get(name)
+	}
+	return b.value, ok
+}
+
+// Set declares name in this environment, replacing any variable of the
+// same name. It returns ErrConstant if name is a constant here.
+func (e *Environment) Set(name string, val Object) (Object, error) {
+	return e.declare(name, val, false)
+}
+
+// SetConst declares name as a constant in this environment
+func (e *Environment) SetConst(name string, val Object) (Object, error) {
+	return e.declare(name, val, true)
+}
+
+func (e *Environment) declare(name string, val Object, constant bool) (Object, error) {
+	if b, ok := e.store[name]; ok && b.constant {
+		return nil, ErrConstant
+	}
+	e.store[name] = binding{value: val, constant: constant}
+	return val, nil
+}
+
+// Assign changes the value of an existing variable in the innermost
+// environment that binds name
+func (e *Environment) Assign(name string, val Object) (Object, error) {
+	for env := e; env != nil; env = env.outer {
+		b, ok := env.store[name]
+		if !ok {
+			continue
+		}
+		if b.constant {
+			return nil, ErrConstant
+		}
+		env.store[name] = binding{value: val}
+		return val, nil
+	}
+	return nil, ErrUndefined
+}
@@ -0,0 +1,281 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"skibidilang/ast"
+	"skibidilang/code"
+	"skibidilang/token"
+	"strconv"
+	"strings"
+)
+
+type ObjectType string
+
+const (
+	INTEGER_OBJ           = "INTEGER"
+	FLOAT_OBJ             = "FLOAT"
+	BOOLEAN_OBJ           = "BOOLEAN"
+	STRING_OBJ            = "STRING"
+	NULL_OBJ              = "NULL"
+	RETURN_VALUE_OBJ      = "RETURN_VALUE"
+	BREAK_OBJ             = "BREAK"
+	CONTINUE_OBJ          = "CONTINUE"
+	ERROR_OBJ             = "ERROR"
+	FUNCTION_OBJ          = "FUNCTION"
+	BUILTIN_OBJ           = "BUILTIN"
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+	CLOSURE_OBJ           = "CLOSURE"
+	CELL_OBJ              = "CELL"
+	ARRAY_OBJ             = "ARRAY"
+	HASH_OBJ              = "HASH"
+)
+
+// Object is a value produced by evaluating a program
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+// Hashable is implemented by the objects that can be used as hash keys
+type Hashable interface {
+	HashKey() HashKey
+}
+
+type Integer struct {
+	Value int64
+}
+
+type Float struct {
+	Value float64
+}
+
+type Boolean struct {
+	Value bool
+}
+
+type String struct {
+	Value string
+}
+
+type Null struct{}
+
+// ReturnValue wraps the value of a goon statement while it unwinds to the
+// enclosing function call
+type ReturnValue struct {
+	Value Object
+}
+
+// Break and Continue are the signals of yeet and mew statements, they
+// unwind to the enclosing loop
+type Break struct{}
+
+type Continue struct{}
+
+// Error is a runtime error, it unwinds the whole program
+type Error struct {
+	Message string
+	Pos     token.Position // where the error happened, if known
+	Exit    bool           // raised by exit, the program ends with Status
+	Status  int
+}
+
+type Function struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+// BuiltinFunction is the implementation of a function provided by the
+// interpreter. It reports misuse by returning an *Error.
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+// CompiledFunction is the bytecode of a function literal
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumLocals     int
+	NumParameters int
+	Name          string         // the name it was bound to, if any
+	Lines         code.LineTable // source positions of the instructions
+	LocalNames    []string       // names of the locals by slot, for errors
+	FreeNames     []string       // names of the free variables, for errors
+}
+
+// Closure is a compiled function together with the free variables it
+// captured
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []*Cell
+}
+
+// Cell holds a local variable captured by a closure. The function the
+// variable belongs to and the closures capturing it share the cell, so
+// that they see each other's assignments.
+type Cell struct {
+	Value Object
+}
+
+type Array struct {
+	Elements []Object
+}
+
+// HashKey identifies a hash key by its type and value
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash maps keys to values and remembers the order the keys were added in
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	Keys  []HashKey // in insertion order
+}
+
+func (i *Integer) Type() ObjectType           { return INTEGER_OBJ }
+func (i *Integer) Inspect() string            { return strconv.FormatInt(i.Value, 10) }
+func (f *Float) Type() ObjectType             { return FLOAT_OBJ }
+func (f *Float) Inspect() string              { return strconv.FormatFloat(f.Value, 'g', -1, 64) }
+func (b *Boolean) Type() ObjectType           { return BOOLEAN_OBJ }
+func (s *String) Type() ObjectType            { return STRING_OBJ }
+func (s *String) Inspect() string             { return s.Value }
+func (n *Null) Type() ObjectType              { return NULL_OBJ }
+func (n *Null) Inspect() string               { return "null" }
+func (rv *ReturnValue) Type() ObjectType      { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string       { return rv.Value.Inspect() }
+func (b *Break) Type() ObjectType             { return BREAK_OBJ }
+func (b *Break) Inspect() string              { return "yeet" }
+func (c *Continue) Type() ObjectType          { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string           { return "mew" }
+func (e *Error) Type() ObjectType             { return ERROR_OBJ }
+func (f *Function) Type() ObjectType          { return FUNCTION_OBJ }
+func (b *Builtin) Type() ObjectType           { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string            { return "builtin function " + b.Name }
+func (cf *CompiledFunction) Type() ObjectType { return COMPILED_FUNCTION_OBJ }
+func (c *Closure) Type() ObjectType           { return CLOSURE_OBJ }
+func (c *Cell) Type() ObjectType              { return CELL_OBJ }
+func (c *Cell) Inspect() string               { return fmt.Sprintf("Cell[%p]", c) }
+func (a *Array) Type() ObjectType             { return ARRAY_OBJ }
+func (h *Hash) Type() ObjectType              { return HASH_OBJ }
+
+// Booleans are spelled alpha and beta in the language
+func (b *Boolean) Inspect() string {
+	if b.Value {
+		return "alpha"
+	}
+	return "beta"
+}
+
+func (e *Error) Inspect() string { return "ERROR: " + e.Message }
+
+// Error makes runtime errors usable as Go errors, prefixed with their
+// position like parse errors
+func (e *Error) Error() string {
+	if e.Pos.IsValid() || e.Pos.Filename != "" {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString("ohio(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(f.Body.String())
+	return out.String()
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+func (c *Closure) Inspect() string {
+	if c.Fn.Name != "" {
+		return "ohio " + c.Fn.Name
+	}
+	return fmt.Sprintf("Closure[%p]", c)
+}
+
+func (a *Array) Inspect() string {
+	elements := []string{}
+	for _, e := range a.Elements {
+		elements = append(elements, inspectElement(e))
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (h *Hash) Inspect() string {
+	pairs := []string{}
+	for _, key := range h.Keys {
+		pair := h.Pairs[key]
+		pairs = append(pairs, inspectElement(pair.Key)+": "+inspectElement(pair.Value))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// inspectElement quotes strings inside arrays and hashes, so that ["a, b"]
+// and ["a", "b"] look different
+func inspectElement(obj Object) string {
+	if s, ok := obj.(*String); ok {
+		return strconv.Quote(s.Value)
+	}
+	return obj.Inspect()
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+// NewHash returns an empty hash
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+// Set adds or replaces the value stored under key
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.Keys = append(h.Keys, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key.(Object), Value: value}
+}
+
+// Get returns the value stored under key
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair.Value, ok
+}
+
+// Errorf returns a runtime error at pos
+func Errorf(pos token.Position, format string, a ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...), Pos: pos}
+}
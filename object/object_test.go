@@ -0,0 +1,101 @@
+package object
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStringHashKey(t *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff1 := &String{Value: "My name is johnny"}
+	diff2 := &String{Value: "My name is johnny"}
+	if hello1.HashKey() != hello2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+	if diff1.HashKey() != diff2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+	if hello1.HashKey() == diff1.HashKey() {
+		t.Errorf("strings with different content have same hash keys")
+	}
+	if (&Integer{Value: 1}).HashKey() == (&Boolean{Value: true}).HashKey() {
+		t.Errorf("objects of different types have same hash keys")
+	}
+}
+
+func TestHashKeepsInsertionOrder(t *testing.T) {
+	hash := NewHash()
+	hash.Set(&String{Value: "b"}, &Integer{Value: 1})
+	hash.Set(&Integer{Value: 2}, &Boolean{Value: true})
+	hash.Set(&String{Value: "b"}, &Integer{Value: 3})
+	if hash.Inspect() != `{"b": 3, 2: alpha}` {
+		t.Errorf("hash.Inspect() wrong. got=%s", hash.Inspect())
+	}
+	if _, ok := hash.Get(&String{Value: "c"}); ok {
+		t.Errorf("hash has a value for a missing key")
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", &Integer{Value: 1})
+	outer.SetConst("c", &Integer{Value: 2})
+	inner := NewEnclosedEnvironment(outer)
+
+	if _, err := inner.Assign("x", &Integer{Value: 5}); err != nil {
+		t.Fatalf("Assign returned %v", err)
+	}
+	if val, _ := outer.Get("x"); val.Inspect() != "5" {
+		t.Errorf("assignment did not change the outer binding. got=%s", val.Inspect())
+	}
+	if _, err := inner.Assign("c", &Integer{Value: 5}); !errors.Is(err, ErrConstant) {
+		t.Errorf("assigning to a constant returned %v", err)
+	}
+	if _, err := inner.Assign("y", &Integer{Value: 5}); !errors.Is(err, ErrUndefined) {
+		t.Errorf("assigning to an unbound name returned %v", err)
+	}
+	if _, err := outer.Set("c", &Integer{Value: 5}); !errors.Is(err, ErrConstant) {
+		t.Errorf("redeclaring a constant returned %v", err)
+	}
+	// an inner scope may shadow a constant
+	if _, err := inner.Set("c", &Integer{Value: 5}); err != nil {
+		t.Errorf("shadowing a constant returned %v", err)
+	}
+	if val, _ := outer.Get("c"); val.Inspect() != "2" {
+		t.Errorf("shadowing changed the constant. got=%s", val.Inspect())
+	}
+}
+
+func TestExitBuiltin(t *testing.T) {
+	exit := GetBuiltinByName("exit")
+	tests := []struct {
+		args   []Object
+		status int
+	}{
+		{nil, 0},
+		{[]Object{&Integer{Value: 3}}, 3},
+		{[]Object{&Integer{Value: 255}}, 255},
+	}
+	for _, tt := range tests {
+		result, ok := exit.Fn(tt.args...).(*Error)
+		if !ok || !result.Exit || result.Status != tt.status {
+			t.Errorf("exit%v: wrong result. want status %d, got=%#v", tt.args, tt.status, result)
+		}
+	}
+
+	invalid := []struct {
+		arg  Object
+		want string
+	}{
+		{&String{Value: "3"}, "argument to `exit` not supported, got STRING"},
+		{&Integer{Value: 256}, "exit status must be between 0 and 255, got 256"},
+		{&Integer{Value: -1}, "exit status must be between 0 and 255, got -1"},
+	}
+	for _, tt := range invalid {
+		result, ok := exit.Fn(tt.arg).(*Error)
+		if !ok || result.Exit || result.Message != tt.want {
+			t.Errorf("exit(%s): want error %q, got=%#v", tt.arg.Inspect(), tt.want, result)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"fmt"
+	"skibidilang/token"
+	"sort"
+	"strings"
+)
+
+// ErrorKind classifies a parse error
+type ErrorKind int
+
+const (
+	ErrUnexpectedToken    ErrorKind = iota // a different token was expected
+	ErrLexical                             // the lexer could not read a token
+	ErrNoPrefixParseFn                     // the token cannot start an expression
+	ErrInvalidLiteral                      // a literal could not be converted to a value
+	ErrInvalidOperand                      // the operand cannot be used with the operator
+	ErrInvalidAssignment                   // the left side of an assignment cannot be assigned to
+	ErrMisplacedStatement                  // the statement is not allowed where it appears
+)
+
+var errorKindNames = map[ErrorKind]string{
+	ErrUnexpectedToken:    "unexpected token",
+	ErrLexical:            "lexical error",
+	ErrNoPrefixParseFn:    "no prefix parse function",
+	ErrInvalidLiteral:     "invalid literal",
+	ErrInvalidOperand:     "invalid operand",
+	ErrInvalidAssignment:  "invalid assignment",
+	ErrMisplacedStatement: "misplaced statement",
+}
+
+func (k ErrorKind) String() string {
+	if name, ok := errorKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// ParseError describes a single problem found by the parser
+type ParseError struct {
+	Pos      token.Position
+	Kind     ErrorKind
+	Expected []token.TokenType // token types that would have been accepted, if known
+	Actual   token.Token       // the offending token
+	Msg      string
+}
+
+func (e *ParseError) Error() string {
+	if e.Pos.IsValid() || e.Pos.Filename != "" {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return e.Msg
+}
+
+// ErrorList is a list of parse errors. It implements sort.Interface
+// ordering errors by position.
+type ErrorList []*ParseError
+
+func (el ErrorList) Len() int      { return len(el) }
+func (el ErrorList) Swap(i, j int) { el[i], el[j] = el[j], el[i] }
+func (el ErrorList) Less(i, j int) bool {
+	a, b := el[i].Pos, el[j].Pos
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	if a.Offset != b.Offset {
+		return a.Offset < b.Offset
+	}
+	return el[i].Msg < el[j].Msg
+}
+
+// Sort sorts the list by position
+func (el ErrorList) Sort() {
+	sort.Sort(el)
+}
+
+// RemoveMultiples sorts the list and removes duplicates, errors with the
+// same position and message as another one. Different errors on the same
+// line are all kept.
+func (el *ErrorList) RemoveMultiples() {
+	sort.Sort(el)
+	i := 0
+	for _, e := range *el {
+		if i == 0 || e.Pos != (*el)[i-1].Pos || e.Msg != (*el)[i-1].Msg {
+			(*el)[i] = e
+			i++
+		}
+	}
+	*el = (*el)[:i]
+}
+
+func (el ErrorList) Error() string {
+	switch len(el) {
+	case 0:
+		return "no errors"
+	case 1:
+		return el[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", el[0], len(el)-1)
+}
+
+// Err returns an error equivalent to the list, or nil if the list is empty
+func (el ErrorList) Err() error {
+	if len(el) == 0 {
+		return nil
+	}
+	return el
+}
+
+// Messages returns the error messages including their positions
+func (el ErrorList) Messages() []string {
+	msgs := make([]string, len(el))
+	for i, e := range el {
+		msgs[i] = e.Error()
+	}
+	return msgs
+}
+
+func expectedString(types []token.TokenType) string {
+	parts := make([]string, len(types))
+	for i, t := range types {
+		parts[i] = string(t)
+	}
+	return strings.Join(parts, " or ")
+}
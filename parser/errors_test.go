@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"skibidilang/lexer"
+	"skibidilang/token"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	input := `skibidi = 5;`
+	l := lexer.NewFile("main.skb", input)
+	p := New(l)
+	_, errs := p.ParseProgramWithErrors()
+	if len(errs) == 0 {
+		t.Fatalf("expected parse errors, got none")
+	}
+	err := errs[0]
+	if err.Kind != ErrUnexpectedToken {
+		t.Errorf("err.Kind wrong. expected=%s, got=%s", ErrUnexpectedToken, err.Kind)
+	}
+	if len(err.Expected) != 1 || err.Expected[0] != token.IDENT {
+		t.Errorf("err.Expected wrong. got=%v", err.Expected)
+	}
+	if err.Actual.Type != token.ASSIGN {
+		t.Errorf("err.Actual wrong. got=%q", err.Actual.Type)
+	}
+	if err.Pos.Line != 1 || err.Pos.Column != 9 {
+		t.Errorf("err.Pos wrong. got=%s", err.Pos)
+	}
+	expected := "main.skb:1:9: expected next token to be IDENT, got = instead"
+	if err.Error() != expected {
+		t.Errorf("err.Error() wrong. expected=%q, got=%q", expected, err.Error())
+	}
+}
+
+func TestParseReturnsErrorList(t *testing.T) {
+	_, err := Parse("main.skb", "skibidi x = 5;")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = Parse("main.skb", "skibidi 5;")
+	list, ok := err.(ErrorList)
+	if !ok {
+		t.Fatalf("err is not ErrorList. got=%T", err)
+	}
+	if len(list) == 0 {
+		t.Fatalf("expected errors, got none")
+	}
+}
+
+func TestErrorListSortAndRemoveMultiples(t *testing.T) {
+	pos := func(line, column, offset int) token.Position {
+		return token.Position{Line: line, Column: column, Offset: offset}
+	}
+	list := ErrorList{
+		{Pos: pos(2, 1, 10), Msg: "c"},
+		{Pos: pos(1, 5, 4), Msg: "b"},
+		{Pos: pos(1, 1, 0), Msg: "a"},
+		{Pos: pos(2, 3, 12), Msg: "d"},
+	}
+	list.Sort()
+	for i, msg := range []string{"a", "b", "c", "d"} {
+		if list[i].Msg != msg {
+			t.Errorf("list[%d].Msg wrong. expected=%q, got=%q", i, msg, list[i].Msg)
+		}
+	}
+	// duplicates are removed, other errors on the same line are kept
+	list = append(list,
+		&ParseError{Pos: pos(1, 1, 0), Msg: "a"},
+		&ParseError{Pos: pos(2, 3, 12), Msg: "d"},
+		&ParseError{Pos: pos(2, 3, 12), Msg: "e"},
+	)
+	list.RemoveMultiples()
+	expected := []string{"a", "b", "c", "d", "e"}
+	if len(list) != len(expected) {
+		t.Fatalf("len(list) wrong. expected=%d, got=%d", len(expected), len(list))
+	}
+	for i, msg := range expected {
+		if list[i].Msg != msg {
+			t.Errorf("list[%d].Msg wrong. expected=%q, got=%q", i, msg, list[i].Msg)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"skibidilang/ast"
 	"skibidilang/lexer"
 	"skibidilang/token"
@@ -11,30 +13,99 @@ import (
 const (
 	_ int = iota
 	lowest
-	equals      // ==
-	lessgreater // > or <
-	add         // +
-	multiply    // *
-	prefix      // -X or !X
+	assign      // =, +=, -=, *=, /=, %= or ^=
+	logicalOr   // ||
+	logicalAnd  // &&
+	equals      // == or !=
+	lessgreater // >, <, >= or <=
+	add         // +, -, | or ^
+	multiply    // *, /, %, <<, >>, & or &^
+	prefix      // -X, !X or ~X
+	power       // X ** Y
+	postfix     // X++ or X--
 	call        // myFunction(X)
+	index       // array[index]
 )
 
+// statementKeywords are tokens that can only begin a new statement. The
+// parser resynchronizes on them after a syntax error.
+var statementKeywords = map[token.TokenType]bool{
+	token.LET:      true,
+	token.CONST:    true,
+	token.RETURN:   true,
+	token.IF:       true,
+	token.WHILE:    true,
+	token.FOR:      true,
+	token.BREAK:    true,
+	token.CONTINUE: true,
+}
+
+// precedences lists every operator that follows its left operand. Tokens
+// without a dedicated infix parse function are binary operators parsed as
+// ast.InfixExpression, assignment operators are parsed as
+// ast.AssignExpression.
 var precedences = map[token.TokenType]int{
-	token.EQ:       equals,
-	token.NEQ:      equals,
-	token.LT:       lessgreater,
-	token.GT:       lessgreater,
-	token.ADD:      add,
-	token.SUB:      add,
-	token.SLASH:    multiply,
-	token.ASTERISK: multiply,
+	token.ASSIGN:     assign,
+	token.ADD_ASSIGN: assign,
+	token.SUB_ASSIGN: assign,
+	token.MUL_ASSIGN: assign,
+	token.QUO_ASSIGN: assign,
+	token.REM_ASSIGN: assign,
+	token.XOR_ASSIGN: assign,
+	token.LOR:        logicalOr,
+	token.LAND:       logicalAnd,
+	token.EQ:         equals,
+	token.NEQ:        equals,
+	token.LT:         lessgreater,
+	token.GT:         lessgreater,
+	token.LEQ:        lessgreater,
+	token.GEQ:        lessgreater,
+	token.ADD:        add,
+	token.SUB:        add,
+	token.OR:         add,
+	token.XOR:        add,
+	token.SLASH:      multiply,
+	token.ASTERISK:   multiply,
+	token.MOD:        multiply,
+	token.SHL:        multiply,
+	token.SHR:        multiply,
+	token.AND:        multiply,
+	token.AND_NOT:    multiply,
+	token.POWER:      power,
+	token.INC:        postfix,
+	token.DEC:        postfix,
+	token.LPAREN:     call,
+	token.LBRACK:     index,
+}
+
+// rightAssociative lists the binary operators that group right to left,
+// all others group left to right
+var rightAssociative = map[token.TokenType]bool{
+	token.POWER:      true,
+	token.ASSIGN:     true,
+	token.ADD_ASSIGN: true,
+	token.SUB_ASSIGN: true,
+	token.MUL_ASSIGN: true,
+	token.QUO_ASSIGN: true,
+	token.REM_ASSIGN: true,
+	token.XOR_ASSIGN: true,
 }
 
 type Parser struct {
 	l              *lexer.Lexer
 	curToken       token.Token
 	peekToken      token.Token
-	errors         []string
+	errors         ErrorList
+	errorCount     int               // number of reported errors, including suppressed duplicates
+	recovered      int               // errorCount at the last resynchronization
+	atRbrace       bool              // the last statement failed on a closing brace it did not consume
+	loopDepth      int               // number of loops enclosing the current statement in this function
+	inValueIf      bool              // inside an if expression whose value is used, and no loop within it
+	statementIf    bool              // the if expression about to be parsed makes up a whole statement
+	branches       []token.Token     // yeet and mew inside the if statements being parsed
+	scopes         []map[string]bool // names declared so far per function, true for constants
+	lexErrors      int               // lexer errors already looked at
+	peekLexErrors  []lexer.Error
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -47,7 +118,7 @@ type (
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		scopes: []map[string]bool{{}},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
@@ -58,24 +129,35 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns[token.FALSE] = prefixParseFn(p.parseBoolean)
 	p.prefixParseFns[token.IDENT] = prefixParseFn(p.parseIdentifier)
 	p.prefixParseFns[token.INT] = prefixParseFn(p.parseInteger)
+	p.prefixParseFns[token.FLOAT] = prefixParseFn(p.parseFloat)
+	p.prefixParseFns[token.STRING] = prefixParseFn(p.parseStringLiteral)
+	p.prefixParseFns[token.TEMPLATE_HEAD] = prefixParseFn(p.parseInterpolatedString)
 	p.prefixParseFns[token.NOT] = prefixParseFn(p.parsePrefixExpression)
 	p.prefixParseFns[token.SUB] = prefixParseFn(p.parsePrefixExpression)
+	p.prefixParseFns[token.BITNOT] = prefixParseFn(p.parsePrefixExpression)
+	p.prefixParseFns[token.INC] = prefixParseFn(p.parseIncDecPrefixExpression)
+	p.prefixParseFns[token.DEC] = prefixParseFn(p.parseIncDecPrefixExpression)
 	p.prefixParseFns[token.LPAREN] = prefixParseFn(p.parseGroupedExpression)
-	p.infixParseFns[token.ADD] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.SUB] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.NOT] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.ASTERISK] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.SLASH] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.AND] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.OR] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.LT] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.GT] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.POWER] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.EQ] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.NEQ] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.INC] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.LEQ] = infixParseFn(p.parseInfixExpression)
-	p.infixParseFns[token.GEQ] = infixParseFn(p.parseInfixExpression)
+	p.prefixParseFns[token.IF] = prefixParseFn(p.parseIfExpression)
+	p.prefixParseFns[token.FUNCTION] = prefixParseFn(p.parseFunctionLiteral)
+	p.prefixParseFns[token.LBRACK] = prefixParseFn(p.parseArrayLiteral)
+	// Blocks are only parsed where the grammar requires them (after if,
+	// else and ohio), so a { anywhere an expression is expected starts a
+	// hash literal.
+	p.prefixParseFns[token.LBRACE] = prefixParseFn(p.parseHashLiteral)
+	for tokenType, precedence := range precedences {
+		if precedence == assign {
+			p.infixParseFns[tokenType] = infixParseFn(p.parseAssignExpression)
+		} else {
+			p.infixParseFns[tokenType] = infixParseFn(p.parseInfixExpression)
+		}
+	}
+	p.infixParseFns[token.LAND] = infixParseFn(p.parseLogicalExpression)
+	p.infixParseFns[token.LOR] = infixParseFn(p.parseLogicalExpression)
+	p.infixParseFns[token.LPAREN] = infixParseFn(p.parseCallExpression)
+	p.infixParseFns[token.LBRACK] = infixParseFn(p.parseIndexExpression)
+	p.infixParseFns[token.INC] = infixParseFn(p.parsePostfixExpression)
+	p.infixParseFns[token.DEC] = infixParseFn(p.parsePostfixExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -85,34 +167,96 @@ func New(l *lexer.Lexer) *Parser {
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
+	// lexer errors are reported once their token becomes current, so they
+	// are attributed to the statement containing it
+	p.reportPeekLexErrors(p.curToken)
 	p.peekToken = p.l.NextToken()
+	for p.peekTokenIs(token.COMMENT) {
+		p.peekToken = p.l.NextToken()
+	}
+	errs := p.l.Errors()
+	p.peekLexErrors = errs[p.lexErrors:]
+	p.lexErrors = len(errs)
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
-	for p.curToken.Type != token.EOF {
+	for !p.curTokenIs(token.EOF) {
 		statement := p.parseStatement()
-		if statement != nil {
-			program.Statements = append(program.Statements, statement)
-		}
+		program.Statements = append(program.Statements, statement)
 		p.nextToken()
 	}
 	return program
 }
 
+// ParseProgramWithErrors parses the program and returns it together with
+// every error found on the way
+func (p *Parser) ParseProgramWithErrors() (*ast.Program, ErrorList) {
+	program := p.ParseProgram()
+	return program, p.errors
+}
+
+// Parse parses the source of a single file. The returned error, if not
+// nil, is an ErrorList.
+func Parse(filename, input string) (*ast.Program, error) {
+	p := New(lexer.NewFile(filename, input))
+	program, errs := p.ParseProgramWithErrors()
+	return program, errs.Err()
+}
+
+// parseStatement parses the statement starting at the current token. It
+// never returns nil: when the statement cannot be parsed the parser skips
+// to the next statement boundary and returns an *ast.BadStatement.
 func (p *Parser) parseStatement() ast.Statement {
+	start := p.curToken
+	errorCount := p.errorCount
+	p.atRbrace = false
+	var statement ast.Statement
 	switch p.curToken.Type {
-	case token.LET:
-		return p.parseLetStatement()
+	case token.LET, token.CONST:
+		statement = p.parseLetStatement()
 	case token.RETURN:
-		return p.parseReturnStatement()
+		statement = p.parseReturnStatement()
+	case token.WHILE:
+		statement = p.parseWhileStatement()
+	case token.FOR:
+		statement = p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		statement = p.parseBranchStatement()
 	default:
-		return p.parseExpressionStatement()
+		statement = p.parseExpressionStatement()
+	}
+	if p.errorCount > errorCount && p.errorCount > p.recovered {
+		p.synchronize()
+		p.recovered = p.errorCount
+		if statement == nil {
+			statement = &ast.BadStatement{Token: start, To: p.curToken.End}
+		}
+	}
+	return statement
+}
+
+// synchronize skips tokens after a syntax error until the end of the
+// current statement: a semicolon, or the token before a statement keyword,
+// a closing brace or the end of the input.
+func (p *Parser) synchronize() {
+	p.atRbrace = false
+	if p.curTokenIs(token.RBRACE) && p.errors[len(p.errors)-1].Pos == p.curToken.Pos {
+		// the brace is the offending token, leave it to the enclosing block
+		p.atRbrace = true
+		return
+	}
+	for !p.curTokenIs(token.SEMICOLON) && !p.curTokenIs(token.EOF) {
+		if statementKeywords[p.peekToken.Type] ||
+			p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF) {
+			return
+		}
+		p.nextToken()
 	}
 }
 
-func (p *Parser) parseLetStatement() *ast.LetStatement {
+func (p *Parser) parseLetStatement() ast.Statement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 	if !p.expectPeek(token.IDENT) {
 		return nil
@@ -121,9 +265,11 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
-	// TODO: We're skipping the expressions until we
-	// encounter a semicolon
-	for !p.curTokenIs(token.SEMICOLON) {
+	p.nextToken()
+	stmt.Value = p.parseExpression(lowest)
+	// declared after the value, which still sees an outer binding of the name
+	p.declare(stmt.Name, stmt.IsConst())
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
@@ -131,17 +277,151 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
+	// a bare return has no value
+	if p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF) {
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	}
+	p.nextToken()
+	stmt.ReturnValue = p.parseExpression(lowest)
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Condition = p.parseExpression(lowest)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+	return stmt
+}
+
+// parseForStatement parses both loop forms: rizz (init; cond; post) { ... }
+// and rizz x in xs { ... }
+func (p *Parser) parseForStatement() ast.Statement {
+	if p.peekTokenIs(token.IDENT) {
+		return p.parseForInStatement()
+	}
+	stmt := &ast.ForStatement{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	switch p.curToken.Type {
+	case token.SEMICOLON:
+	case token.LET:
+		stmt.Init = p.parseLetStatement()
+		if stmt.Init == nil {
+			return nil
+		}
+		// parseLetStatement consumes the semicolon when it is there
+		if !p.curTokenIs(token.SEMICOLON) && !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	default:
+		stmt.Init = &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseExpression(lowest)}
+		if !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+	if !p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+		stmt.Condition = p.parseExpression(lowest)
+	}
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+	if !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		stmt.Post = p.parseExpression(lowest)
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+	return stmt
+}
+
+func (p *Parser) parseForInStatement() ast.Statement {
+	stmt := &ast.ForInStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.declare(stmt.Variable, false)
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
 	p.nextToken()
-	// TODO: We're skipping the expressions until we
-	// encounter a semicolon
-	for !p.curTokenIs(token.SEMICOLON) {
+	stmt.Iterable = p.parseExpression(lowest)
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	inValueIf, branches := p.inValueIf, len(p.branches)
+	p.loopDepth++
+	p.inValueIf = false
+	body := p.parseBlockStatement()
+	p.loopDepth--
+	p.inValueIf = inValueIf
+	p.branches = p.branches[:branches]
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return body
+}
+
+// parseBranchStatement parses yeet (break) and mew (continue), both are
+// only allowed inside a loop of the enclosing function. They cannot leave an
+// if expression whose value is used, as in x = if (c) { yeet } else { 1 }.
+func (p *Parser) parseBranchStatement() ast.Statement {
+	var stmt ast.Statement
+	if p.curTokenIs(token.BREAK) {
+		stmt = &ast.BreakStatement{Token: p.curToken}
+	} else {
+		stmt = &ast.ContinueStatement{Token: p.curToken}
+	}
+	switch {
+	case p.inValueIf:
+		p.valueIfError(p.curToken)
+	case p.loopDepth == 0:
+		msg := fmt.Sprintf("%s is not in a loop", p.curToken.Literal)
+		p.error(p.curToken, ErrMisplacedStatement, nil, msg)
+	default:
+		p.branches = append(p.branches, p.curToken)
+	}
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
 }
 
+func (p *Parser) valueIfError(branch token.Token) {
+	msg := fmt.Sprintf("%s cannot leave an if expression whose value is used", branch.Literal)
+	p.error(branch, ErrMisplacedStatement, nil, msg)
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	statement := &ast.ExpressionStatement{Token: p.curToken}
+	p.statementIf = p.curTokenIs(token.IF)
 	statement.Expression = p.parseExpression(lowest)
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -151,14 +431,17 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.error(p.curToken, ErrNoPrefixParseFn, nil, msg)
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
-		p.noPrefixParseFnError(p.curToken.Type)
-		return nil
+		// illegal tokens have already been reported by the lexer
+		if !p.curTokenIs(token.ILLEGAL) {
+			p.noPrefixParseFnError(p.curToken.Type)
+		}
+		return &ast.BadExpression{Token: p.curToken, To: p.curToken.End}
 	}
 	leftExp := prefix()
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
@@ -181,13 +464,52 @@ func (p *Parser) parseInteger() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
-		return nil
+		if errors.Is(err, strconv.ErrRange) {
+			msg = fmt.Sprintf("integer literal %s overflows int64", p.curToken.Literal)
+		}
+		p.error(p.curToken, ErrInvalidLiteral, nil, msg)
+		return &ast.BadExpression{Token: p.curToken, To: p.curToken.End}
 	}
 	literal.Value = value
 	return literal
 }
 
+func (p *Parser) parseFloat() ast.Expression {
+	literal := &ast.FloatLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		if errors.Is(err, strconv.ErrRange) {
+			msg = fmt.Sprintf("float literal %s overflows float64", p.curToken.Literal)
+		}
+		p.error(p.curToken, ErrInvalidLiteral, nil, msg)
+		return &ast.BadExpression{Token: p.curToken, To: p.curToken.End}
+	}
+	literal.Value = value
+	return literal
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Literals = append(str.Literals, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+	for !p.curTokenIs(token.TEMPLATE_TAIL) {
+		p.nextToken()
+		str.Expressions = append(str.Expressions, p.parseExpression(lowest))
+		if !p.peekTokenIs(token.TEMPLATE_MIDDLE) && !p.peekTokenIs(token.TEMPLATE_TAIL) {
+			msg := fmt.Sprintf("expected } to end interpolated expression, got %s instead", p.peekToken.Type)
+			p.peekError([]token.TokenType{token.TEMPLATE_MIDDLE, token.TEMPLATE_TAIL}, msg)
+			return &ast.BadExpression{Token: str.Token, To: p.curToken.End}
+		}
+		p.nextToken()
+		str.Literals = append(str.Literals, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+	}
+	return str
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -198,10 +520,92 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 		Operator: p.curToken.Literal,
 	}
 	p.nextToken()
+	if expression.Operator == "-" && p.curTokenIs(token.INT) && p.peekPrecedence() <= prefix && isMinInt64(p.curToken.Literal) {
+		// -9223372036854775808 is the smallest int64, but its literal
+		// overflows. The literal holds it instead, negating it gives it
+		// back as int64 arithmetic wraps around.
+		expression.Right = &ast.IntegerLiteral{Token: p.curToken, Value: math.MinInt64}
+		return expression
+	}
 	expression.Right = p.parseExpression(prefix)
 	return expression
 }
 
+// isMinInt64 reports whether literal is the integer 1<<63, the magnitude
+// of the smallest int64
+func isMinInt64(literal string) bool {
+	value, err := strconv.ParseUint(literal, 0, 64)
+	return err == nil && value == 1<<63
+}
+
+// parseIncDecPrefixExpression parses ++X and --X
+func (p *Parser) parseIncDecPrefixExpression() ast.Expression {
+	expression := p.parsePrefixExpression().(*ast.PrefixExpression)
+	if !p.checkAssignable(expression.Token, expression.Right) {
+		return &ast.BadExpression{Token: expression.Token, To: expression.End()}
+	}
+	return expression
+}
+
+func (p *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.PostfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+	if !p.checkAssignable(expression.Token, left) {
+		return &ast.BadExpression{Token: expression.Token, To: expression.End()}
+	}
+	return expression
+}
+
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Target:   target,
+	}
+	valid := p.checkAssignable(expression.Token, target)
+	precedence := p.curPrecedence()
+	if rightAssociative[p.curToken.Type] {
+		precedence--
+	}
+	p.nextToken()
+	expression.Value = p.parseExpression(precedence)
+	if !valid {
+		return &ast.BadExpression{Token: expression.Token, To: expression.End()}
+	}
+	return expression
+}
+
+// checkAssignable reports an error unless exp denotes a storage location
+// the operator op can write to. The language has no other storage
+// locations than variables and elements of arrays and hashes.
+func (p *Parser) checkAssignable(op token.Token, exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		if p.isConst(exp.Value) {
+			msg := fmt.Sprintf("cannot assign to constant %s", exp.Value)
+			p.errorAt(exp.Pos(), op, ErrInvalidAssignment, nil, msg)
+			return false
+		}
+		return true
+	case *ast.IndexExpression:
+		return true
+	case *ast.BadExpression:
+		// already reported
+		return false
+	}
+	if op.Type == token.INC || op.Type == token.DEC {
+		msg := fmt.Sprintf("invalid operand for %s: %s is not assignable", op.Literal, exp)
+		p.errorAt(exp.Pos(), op, ErrInvalidOperand, nil, msg)
+		return false
+	}
+	msg := fmt.Sprintf("cannot assign to %s", exp)
+	p.errorAt(exp.Pos(), op, ErrInvalidAssignment, nil, msg)
+	return false
+}
+
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
 		Token:    p.curToken,
@@ -209,20 +613,255 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 	precedence := p.curPrecedence()
+	if rightAssociative[p.curToken.Type] {
+		// binding the right operand one level looser lets an operator of
+		// the same precedence continue it: a ** b ** c is a ** (b ** c)
+		precedence--
+	}
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+	return expression
+}
+
+func (p *Parser) parseLogicalExpression(left ast.Expression) ast.Expression {
+	expression := &ast.LogicalExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+	precedence := p.curPrecedence()
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 	return expression
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	start := p.curToken
 	p.nextToken()
 	exp := p.parseExpression(lowest)
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
 	}
 	return exp
 }
 
+// parseIfExpression parses an if expression. Only one that makes up a whole
+// statement, and so has its value thrown away, may contain yeet and mew.
+func (p *Parser) parseIfExpression() ast.Expression {
+	statement := p.statementIf
+	p.statementIf = false
+	branches := len(p.branches)
+	if !statement {
+		loopDepth, inValueIf := p.loopDepth, p.inValueIf
+		p.inValueIf = p.loopDepth > 0 || p.inValueIf
+		p.loopDepth = 0
+		defer func() { p.loopDepth, p.inValueIf = loopDepth, inValueIf }()
+	}
+	expression := p.parseIf()
+	// the statement goes on after all, as in if (c) { yeet } else { 1 } + 2
+	if !p.peekTokenIs(token.SEMICOLON) && lowest < p.peekPrecedence() && p.infixParseFns[p.peekToken.Type] != nil {
+		for _, branch := range p.branches[branches:] {
+			p.valueIfError(branch)
+		}
+	}
+	p.branches = p.branches[:branches]
+	return expression
+}
+
+func (p *Parser) parseIf() ast.Expression {
+	start := p.curToken
+	expression := &ast.IfExpression{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	p.nextToken()
+	expression.Condition = p.parseExpression(lowest)
+	if !p.expectPeek(token.RPAREN) {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	expression.Consequence = p.parseBlockStatement()
+	if !p.peekTokenIs(token.ELSE) {
+		return expression
+	}
+	p.nextToken()
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		expression.Alternative = p.parseIf()
+		return expression
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	expression.Alternative = p.parseBlockStatement()
+	return expression
+}
+
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+	p.nextToken()
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		statement := p.parseStatement()
+		block.Statements = append(block.Statements, statement)
+		if p.atRbrace {
+			p.atRbrace = false
+			break
+		}
+		p.nextToken()
+	}
+	if !p.curTokenIs(token.RBRACE) {
+		p.error(p.curToken, ErrUnexpectedToken, []token.TokenType{token.RBRACE},
+			fmt.Sprintf("expected %s to close block, got %s instead", token.RBRACE, p.curToken.Type))
+		return block
+	}
+	block.Rbrace = p.curToken
+	return block
+}
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	start := p.curToken
+	literal := &ast.FunctionLiteral{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	parameters, ok := p.parseFunctionParameters()
+	if !ok {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	literal.Parameters = parameters
+	if !p.expectPeek(token.LBRACE) {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	// loops outside the function cannot be left from inside it
+	loopDepth, inValueIf := p.loopDepth, p.inValueIf
+	p.loopDepth, p.inValueIf = 0, false
+	p.scopes = append(p.scopes, map[string]bool{})
+	for _, parameter := range literal.Parameters {
+		p.scopes[len(p.scopes)-1][parameter.Value] = false
+	}
+	literal.Body = p.parseBlockStatement()
+	p.scopes = p.scopes[:len(p.scopes)-1]
+	p.loopDepth, p.inValueIf = loopDepth, inValueIf
+	return literal
+}
+
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, bool) {
+	identifiers := []*ast.Identifier{}
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers, true
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil, false
+	}
+	identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil, false
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil, false
+	}
+	return identifiers, true
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	expression := &ast.CallExpression{Token: p.curToken, Function: function}
+	arguments, ok := p.parseExpressionList(token.RPAREN)
+	if !ok {
+		return &ast.BadExpression{Token: expression.Token, To: p.curToken.End}
+	}
+	expression.Arguments = arguments
+	expression.Rparen = p.curToken
+	return expression
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	elements, ok := p.parseExpressionList(token.RBRACK)
+	if !ok {
+		return &ast.BadExpression{Token: array.Token, To: p.curToken.End}
+	}
+	array.Elements = elements
+	array.Rbrack = p.curToken
+	return array
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken, Pairs: []ast.HashPair{}}
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(lowest)
+		if !p.expectPeek(token.COLON) {
+			return &ast.BadExpression{Token: hash.Token, To: p.curToken.End}
+		}
+		p.nextToken()
+		value := p.parseExpression(lowest)
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return &ast.BadExpression{Token: hash.Token, To: p.curToken.End}
+		}
+	}
+	p.nextToken()
+	hash.Rbrace = p.curToken
+	return hash
+}
+
+// parseIndexExpression parses both left[index] and the slice forms
+// left[low:high], left[:high], left[low:] and left[:]
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	start := p.curToken
+	var low ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		low = p.parseExpression(lowest)
+	}
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		slice := &ast.SliceExpression{Token: start, Left: left, Low: low}
+		if !p.peekTokenIs(token.RBRACK) {
+			p.nextToken()
+			slice.High = p.parseExpression(lowest)
+		}
+		if !p.expectPeek(token.RBRACK) {
+			return &ast.BadExpression{Token: start, To: p.curToken.End}
+		}
+		slice.Rbrack = p.curToken
+		return slice
+	}
+	if !p.expectPeek(token.RBRACK) {
+		return &ast.BadExpression{Token: start, To: p.curToken.End}
+	}
+	return &ast.IndexExpression{Token: start, Left: left, Index: low, Rbrack: p.curToken}
+}
+
+// parseExpressionList parses comma separated expressions up to the end
+// token, leaving the parser on it
+func (p *Parser) parseExpressionList(end token.TokenType) ([]ast.Expression, bool) {
+	list := []ast.Expression{}
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list, true
+	}
+	p.nextToken()
+	list = append(list, p.parseExpression(lowest))
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(lowest))
+	}
+	if !p.expectPeek(end) {
+		return nil, false
+	}
+	return list, true
+}
+
 func (p *Parser) curTokenIs(t token.TokenType) bool {
 	return p.curToken.Type == t
 }
@@ -240,14 +879,82 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	}
 }
 
+// declare records a binding of name in the innermost function scope. A
+// constant cannot be redeclared in the scope it was declared in.
+func (p *Parser) declare(name *ast.Identifier, constant bool) {
+	scope := p.scopes[len(p.scopes)-1]
+	if scope[name.Value] {
+		msg := fmt.Sprintf("cannot redeclare constant %s", name.Value)
+		p.errorAt(name.Pos(), name.Token, ErrInvalidAssignment, nil, msg)
+		return
+	}
+	scope[name.Value] = constant
+}
+
+// isConst reports whether name refers to a constant binding. Bindings not
+// seen by the parser, such as builtins, are not constant.
+func (p *Parser) isConst(name string) bool {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if constant, ok := p.scopes[i][name]; ok {
+			return constant
+		}
+	}
+	return false
+}
+
+// Errors returns the messages of all errors found so far, prefixed with
+// their positions
 func (p *Parser) Errors() []string {
+	return p.errors.Messages()
+}
+
+// ParseErrors returns all errors found so far
+func (p *Parser) ParseErrors() ErrorList {
 	return p.errors
 }
 
-func (p *Parser) addError(t token.TokenType) {
+// error records a parse error at the position of the offending token
+func (p *Parser) error(tok token.Token, kind ErrorKind, expected []token.TokenType, msg string) {
+	p.errorAt(tok.Pos, tok, kind, expected, msg)
+}
+
+// errorAt records a parse error. An error at the same position as the
+// previous one is almost always a consequence of it and is dropped.
+func (p *Parser) errorAt(pos token.Position, tok token.Token, kind ErrorKind, expected []token.TokenType, msg string) {
+	p.errorCount++
+	if n := len(p.errors); n > 0 && p.errors[n-1].Pos == pos {
+		return
+	}
+	p.errors = append(p.errors, &ParseError{
+		Pos:      pos,
+		Kind:     kind,
+		Expected: expected,
+		Actual:   tok,
+		Msg:      msg,
+	})
+}
+
+func (p *Parser) addError(t ...token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
-		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+		expectedString(t), p.peekToken.Type)
+	p.peekError(t, msg)
+}
+
+// peekError reports that the next token is not one of the expected types.
+// If it is an illegal token, the lexer's explanation is reported instead.
+func (p *Parser) peekError(expected []token.TokenType, msg string) {
+	if p.peekTokenIs(token.ILLEGAL) && len(p.peekLexErrors) > 0 {
+		p.reportPeekLexErrors(p.peekToken)
+		return
+	}
+	p.error(p.peekToken, ErrUnexpectedToken, expected, msg)
+}
+
+func (p *Parser) reportPeekLexErrors(tok token.Token) {
+	for _, err := range p.peekLexErrors {
+		p.errorAt(err.Pos, tok, ErrLexical, nil, err.Msg)
+	}
+	p.peekLexErrors = nil
 }
 
 func (p *Parser) peekPrecedence() int {
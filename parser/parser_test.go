@@ -2,36 +2,62 @@ package parser
 
 import (
 	"fmt"
+	"math"
 	"skibidilang/ast"
 	"skibidilang/lexer"
 	"testing"
 )
 
 func TestLetStatements(t *testing.T) {
-	input := `
-skibidi x = 5;
-skibidi y = 10;
-skibidi foobar = 838383;
-`
-	l := lexer.New(input)
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"skibidi x = 5;", "x", 5},
+		{"skibidi y = alpha;", "y", true},
+		{"skibidi foobar = y;", "foobar", "y"},
+		{"skibidi z = 838383", "z", 838383},
+		{"skibidi café = 变量;", "café", "变量"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program == nil {
+			t.Fatalf("ParseProgram() returned nil")
+		}
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+				len(program.Statements))
+		}
+		statement := program.Statements[0]
+		if !testLetStatement(t, statement, tt.expectedIdentifier) {
+			return
+		}
+		value := statement.(*ast.LetStatement).Value
+		if !testLiteralExpression(t, value, tt.expectedValue) {
+			return
+		}
+	}
+}
+func TestLetStatementWithExpression(t *testing.T) {
+	l := lexer.New("skibidi sum = x + 10;")
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
-	if program == nil {
-		t.Fatalf("ParseProgram() returned nil")
-	}
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statements. got=%d",
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d",
 			len(program.Statements))
 	}
-	tests := []string{"x", "y", "foobar"}
-	for i, expectedIdentifier := range tests {
-		statement := program.Statements[i]
-		if !testLetStatement(t, statement, expectedIdentifier) {
-			return
-		}
+	statement := program.Statements[0]
+	if !testLetStatement(t, statement, "sum") {
+		return
 	}
+	testInfixExpression(t, statement.(*ast.LetStatement).Value, "x", "+", 10)
 }
+
 func testLetStatement(t *testing.T, statement ast.Statement, name string) bool {
 	if statement.TokenLiteral() != "skibidi" {
 		t.Errorf("s.TokenLiteral not 'skibidi'. got=%q", statement.TokenLiteral())
@@ -53,29 +79,44 @@ func testLetStatement(t *testing.T, statement ast.Statement, name string) bool {
 	return true
 }
 func TestReturnStatements(t *testing.T) {
-	input := `
-	goon 5;
-	goon 10;
-	goon 993322;
-	`
-	l := lexer.New(input)
-	p := New(l)
-	program := p.ParseProgram()
-	checkParserErrors(t, p)
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statements. got=%d",
-			len(program.Statements))
+	tests := []struct {
+		input         string
+		expectedValue interface{}
+	}{
+		{"goon 5;", 5},
+		{"goon beta;", false},
+		{"goon foobar;", "foobar"},
+		{"goon 993322", 993322},
+		{"goon;", nil},
+		{"goon", nil},
 	}
-	for _, statement := range program.Statements {
-		returnStatement, ok := statement.(*ast.ReturnStatement)
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+				len(program.Statements))
+		}
+		returnStatement, ok := program.Statements[0].(*ast.ReturnStatement)
 		if !ok {
-			t.Errorf("statement not *ast.returnStatement. got=%T", statement)
-			continue
+			t.Fatalf("statement not *ast.returnStatement. got=%T", program.Statements[0])
 		}
 		if returnStatement.TokenLiteral() != "goon" {
 			t.Errorf("returnStatement.TokenLiteral not 'goon', got %q",
 				returnStatement.TokenLiteral())
 		}
+		if tt.expectedValue == nil {
+			if returnStatement.ReturnValue != nil {
+				t.Errorf("returnStatement.ReturnValue not nil. got=%s",
+					returnStatement.ReturnValue)
+			}
+			continue
+		}
+		if !testLiteralExpression(t, returnStatement.ReturnValue, tt.expectedValue) {
+			return
+		}
 	}
 }
 func checkParserErrors(t *testing.T, p *Parser) {
@@ -200,6 +241,72 @@ func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
 	return true
 }
 
+func testIdentifier(t *testing.T, exp ast.Expression, value string) bool {
+	ident, ok := exp.(*ast.Identifier)
+	if !ok {
+		t.Errorf("exp not *ast.Identifier. got=%T", exp)
+		return false
+	}
+	if ident.Value != value {
+		t.Errorf("ident.Value not %s. got=%s", value, ident.Value)
+		return false
+	}
+	if ident.TokenLiteral() != value {
+		t.Errorf("ident.TokenLiteral not %s. got=%s", value,
+			ident.TokenLiteral())
+		return false
+	}
+	return true
+}
+
+func testBooleanLiteral(t *testing.T, exp ast.Expression, value bool) bool {
+	boolean, ok := exp.(*ast.Boolean)
+	if !ok {
+		t.Errorf("exp not *ast.Boolean. got=%T", exp)
+		return false
+	}
+	if boolean.Value != value {
+		t.Errorf("boolean.Value not %t. got=%t", value, boolean.Value)
+		return false
+	}
+	return true
+}
+
+func testLiteralExpression(t *testing.T, exp ast.Expression, expected interface{}) bool {
+	switch v := expected.(type) {
+	case int:
+		return testIntegerLiteral(t, exp, int64(v))
+	case int64:
+		return testIntegerLiteral(t, exp, v)
+	case string:
+		return testIdentifier(t, exp, v)
+	case bool:
+		return testBooleanLiteral(t, exp, v)
+	}
+	t.Errorf("type of exp not handled. got=%T", exp)
+	return false
+}
+
+func testInfixExpression(t *testing.T, exp ast.Expression, left interface{},
+	operator string, right interface{}) bool {
+	opExp, ok := exp.(*ast.InfixExpression)
+	if !ok {
+		t.Errorf("exp is not ast.InfixExpression. got=%T(%s)", exp, exp)
+		return false
+	}
+	if !testLiteralExpression(t, opExp.Left, left) {
+		return false
+	}
+	if opExp.Operator != operator {
+		t.Errorf("exp.Operator is not '%s'. got=%q", operator, opExp.Operator)
+		return false
+	}
+	if !testLiteralExpression(t, opExp.Right, right) {
+		return false
+	}
+	return true
+}
+
 func TestParsingInfixExpressions(t *testing.T) {
 	infixTests := []struct {
 		input      string
@@ -328,6 +435,46 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"!(true == true)",
 			"(!(true == true))",
 		},
+		{
+			"a + add(b * c) + d",
+			"((a + add((b * c))) + d)",
+		},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{
+			"add(a + b + c * d / f + g)",
+			"add((((a + b) + ((c * d) / f)) + g))",
+		},
+		{
+			"add(1, 2 * 3)(4)",
+			"add(1, (2 * 3))(4)",
+		},
+		{
+			"-f(x)",
+			"(-f(x))",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"-xs[1:n + 1]",
+			"(-(xs[1:(n + 1)]))",
+		},
+		{
+			"f(x)[0][1:]",
+			"((f(x)[0])[1:])",
+		},
+		{
+			"skibidi x = a + b * c; goon -x",
+			"skibidi x = (a + (b * c));goon (-x);",
+		},
 	}
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
@@ -340,3 +487,1067 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors int
+		expected       []string
+	}{
+		{"skibidi = 5; skibidi y = 10;", 1, []string{"<bad statement>", "skibidi y = 10;"}},
+		{"skibidi x 5 6 7 goon 1;", 1, []string{"<bad statement>", "goon 1;"}},
+		{"5 + ; skibidi y = 10;", 1, []string{"(5 + <bad expression>)", "skibidi y = 10;"}},
+		{"(1 + 2 skibidi y = 10;", 1, []string{"<bad expression>", "skibidi y = 10;"}},
+		{"skibidi x = 5 + ;", 1, []string{"skibidi x = (5 + <bad expression>);"}},
+		{"skibidi x = 5", 0, []string{"skibidi x = 5;"}},
+		{"goon 5", 0, []string{"goon 5;"}},
+		{"if (x) { 1 + } skibidi y = 1;", 1, []string{"if x { (1 + <bad expression>) }", "skibidi y = 1;"}},
+		{"if (x) { skibidi = 1; 2 } 3", 1, []string{"if x { <bad statement> 2 }", "3"}},
+		{"if (x) { 1", 1, []string{"if x { 1 }"}},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != tt.expectedErrors {
+			t.Errorf("input %q: expected %d errors, got %d: %q",
+				tt.input, tt.expectedErrors, len(p.Errors()), p.Errors())
+		}
+		if len(program.Statements) != len(tt.expected) {
+			t.Errorf("input %q: expected %d statements, got %d: %q",
+				tt.input, len(tt.expected), len(program.Statements), program.String())
+			continue
+		}
+		for i, statement := range program.Statements {
+			if statement.String() != tt.expected[i] {
+				t.Errorf("input %q: statement %d expected %q, got %q",
+					tt.input, i, tt.expected[i], statement.String())
+			}
+		}
+	}
+}
+
+func TestParserTerminates(t *testing.T) {
+	inputs := []string{
+		"",
+		"skibidi",
+		"skibidi x",
+		"skibidi x =",
+		"goon",
+		"(",
+		"((((",
+		")))",
+		"}}}",
+		"skibidi x = (1 + ; } goon ) ;",
+		"= = = ; ; ;",
+	}
+	for _, input := range inputs {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		if program == nil {
+			t.Fatalf("input %q: ParseProgram() returned nil", input)
+		}
+		for _, statement := range program.Statements {
+			if statement == nil {
+				t.Errorf("input %q: program contains nil statement", input)
+			}
+		}
+	}
+}
+
+func TestIfExpression(t *testing.T) {
+	input := `if (x < y) { x }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+	if len(exp.Consequence.Statements) != 1 {
+		t.Errorf("consequence is not 1 statements. got=%d\n",
+			len(exp.Consequence.Statements))
+	}
+	consequence, ok := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T",
+			exp.Consequence.Statements[0])
+	}
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative was not nil. got=%+v", exp.Alternative)
+	}
+}
+
+func TestIfElseExpression(t *testing.T) {
+	input := `if (x < y) { x } else { goon y; }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+	alternative, ok := exp.Alternative.(*ast.BlockStatement)
+	if !ok {
+		t.Fatalf("exp.Alternative is not ast.BlockStatement. got=%T", exp.Alternative)
+	}
+	if len(alternative.Statements) != 1 {
+		t.Fatalf("alternative is not 1 statements. got=%d\n",
+			len(alternative.Statements))
+	}
+	returnStatement, ok := alternative.Statements[0].(*ast.ReturnStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ReturnStatement. got=%T",
+			alternative.Statements[0])
+	}
+	testIdentifier(t, returnStatement.ReturnValue, "y")
+}
+
+func TestElseIfChain(t *testing.T) {
+	input := `if (a) { 1 } else if (b) { 2 } else { 3 }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+	elseIf, ok := exp.Alternative.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("exp.Alternative is not ast.IfExpression. got=%T", exp.Alternative)
+	}
+	testIdentifier(t, elseIf.Condition, "b")
+	if _, ok := elseIf.Alternative.(*ast.BlockStatement); !ok {
+		t.Fatalf("elseIf.Alternative is not ast.BlockStatement. got=%T", elseIf.Alternative)
+	}
+	expected := "if a { 1 } else if b { 2 } else { 3 }"
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `ohio(x, y) { x + y; }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	function, ok := stmt.Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+	}
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d\n",
+			len(function.Parameters))
+	}
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
+			len(function.Body.Statements))
+	}
+	bodyStmt, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("function body stmt is not ast.ExpressionStatement. got=%T",
+			function.Body.Statements[0])
+	}
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestFunctionParameterParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+	}{
+		{input: "ohio() {};", expectedParams: []string{}},
+		{input: "ohio(x) {};", expectedParams: []string{"x"}},
+		{input: "ohio(x, y, z) {};", expectedParams: []string{"x", "y", "z"}},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function := stmt.Expression.(*ast.FunctionLiteral)
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d\n",
+				len(tt.expectedParams), len(function.Parameters))
+		}
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("stmt is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T",
+			stmt.Expression)
+	}
+	if !testIdentifier(t, exp.Function, "add") {
+		return
+	}
+	if len(exp.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+	testLiteralExpression(t, exp.Arguments[0], 1)
+	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+	}
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingEmptyArrayLiteral(t *testing.T) {
+	l := lexer.New("[]")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+	if len(array.Elements) != 0 {
+		t.Errorf("len(array.Elements) not 0. got=%d", len(array.Elements))
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stmt.Expression)
+	}
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+	testInfixExpression(t, indexExp.Index, 1, "+", 1)
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		low   interface{}
+		high  interface{}
+	}{
+		{"xs[1:3]", 1, 3},
+		{"xs[:n]", nil, "n"},
+		{"xs[i:]", "i", nil},
+		{"xs[:]", nil, nil},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		slice, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", stmt.Expression)
+		}
+		if !testIdentifier(t, slice.Left, "xs") {
+			return
+		}
+		if tt.low == nil {
+			if slice.Low != nil {
+				t.Errorf("slice.Low not nil. got=%s", slice.Low)
+			}
+		} else {
+			testLiteralExpression(t, slice.Low, tt.low)
+		}
+		if tt.high == nil {
+			if slice.High != nil {
+				t.Errorf("slice.High not nil. got=%s", slice.High)
+			}
+		} else {
+			testLiteralExpression(t, slice.High, tt.high)
+		}
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	input := `{one: 1, 2: two, alpha: 3 + 4}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+	if len(hash.Pairs) != 3 {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+	testIdentifier(t, hash.Pairs[0].Key, "one")
+	testIntegerLiteral(t, hash.Pairs[0].Value, 1)
+	testIntegerLiteral(t, hash.Pairs[1].Key, 2)
+	testIdentifier(t, hash.Pairs[1].Value, "two")
+	testBooleanLiteral(t, hash.Pairs[2].Key, true)
+	testInfixExpression(t, hash.Pairs[2].Value, 3, "+", 4)
+}
+
+func TestParsingEmptyHashLiteral(t *testing.T) {
+	l := lexer.New("{}")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+	if len(hash.Pairs) != 0 {
+		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+}
+
+func TestHashLiteralsAndBlocks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"skibidi h = {1 + 1: 2 * 3, k: [1]};", "skibidi h = {(1 + 1): (2 * 3), k: [1]};"},
+		{"{a: 1}[a]", "({a: 1}[a])"},
+		{"if (x) { {a: 1} } else { {} }", "if x { {a: 1} } else { {} }"},
+		{"ohio() { goon {a: {b: 2}}; }", "ohio() { goon {a: {b: 2}}; }"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestOperatorGrouping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// associativity
+		{"a - b - c", "((a - b) - c)"},
+		{"a / b / c", "((a / b) / c)"},
+		{"a % b % c", "((a % b) % c)"},
+		{"a ** b ** c", "(a ** (b ** c))"},
+		{"a ^ b ^ c", "((a ^ b) ^ c)"},
+		{"a << b << c", "((a << b) << c)"},
+		{"a &^ b &^ c", "((a &^ b) &^ c)"},
+		{"a == b == c", "((a == b) == c)"},
+		{"a < b < c", "((a < b) < c)"},
+		{"a & b & c", "((a & b) & c)"},
+		{"a | b | c", "((a | b) | c)"},
+		// precedence levels
+		{"a + b % c", "(a + (b % c))"},
+		{"a % b * c", "((a % b) * c)"},
+		{"a * b ** c", "(a * (b ** c))"},
+		{"a ** b * c", "((a ** b) * c)"},
+		{"-a ** b", "(-(a ** b))"},
+		{"a ** -b", "(a ** (-b))"},
+		{"!a ** b", "(!(a ** b))"},
+		{"a ** b[0]", "(a ** (b[0]))"},
+		{"f(a) ** 2", "(f(a) ** 2)"},
+		{"~a & b", "((~a) & b)"},
+		{"a + b << c", "(a + (b << c))"},
+		{"a << b + c", "((a << b) + c)"},
+		{"a | b ^ c & d", "((a | b) ^ (c & d))"},
+		{"a & b * c", "((a & b) * c)"},
+		{"a &^ b | c", "((a &^ b) | c)"},
+		{"a >> 1 == b & 1", "((a >> 1) == (b & 1))"},
+		{"a | b < c ^ d", "((a | b) < (c ^ d))"},
+		{"a + b <= c * d", "((a + b) <= (c * d))"},
+		{"a >= b == c <= d", "((a >= b) == (c <= d))"},
+		{"a < b != c > d", "((a < b) != (c > d))"},
+		{"a == b & c != d", "((a == (b & c)) != d)"},
+		{"a & b | c & d", "((a & b) | (c & d))"},
+		{"a | b & c", "(a | (b & c))"},
+		{"a < b && b < c || !d", "(((a < b) && (b < c)) || (!d))"},
+		{"a + b * c ** d ** e % f - g", "((a + ((b * (c ** (d ** e))) % f)) - g)"},
+		{"(a | b) & c", "((a | b) & c)"},
+		{"a && b && c", "((a && b) && c)"},
+		{"a || b || c", "((a || b) || c)"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c && d", "((a && b) || (c && d))"},
+		{"x != 0 && 10 / x > 2", "((x != 0) && ((10 / x) > 2))"},
+		{"a < b || a == c", "((a < b) || (a == c))"},
+		{"!a && b", "((!a) && b)"},
+		{"a & b && c | d", "((a & b) && (c | d))"},
+		{"(a || b) && c", "((a || b) && c)"},
+		{"(a + b) ** (c - d)", "((a + b) ** (c - d))"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestNonBinaryOperators(t *testing.T) {
+	// ! and ++ are not binary operators, so they cannot continue an expression
+	for _, input := range []string{"a ! b", "a ++ b"} {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		for _, statement := range program.Statements {
+			if _, ok := statement.(*ast.ExpressionStatement); !ok {
+				continue
+			}
+			if _, ok := statement.(*ast.ExpressionStatement).Expression.(*ast.InfixExpression); ok {
+				t.Errorf("%q parsed as infix expression: %s", input, statement)
+			}
+		}
+	}
+}
+
+func TestIncrementDecrementExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x++", "(x++)"},
+		{"x--", "(x--)"},
+		{"++x", "(++x)"},
+		{"--x", "(--x)"},
+		{"xs[i]++", "((xs[i])++)"},
+		{"++xs[i]", "(++(xs[i]))"},
+		{"-x++", "(-(x++))"},
+		{"x++ + 1", "((x++) + 1)"},
+		{"a ** b++", "(a ** (b++))"},
+		{"x+++y", "((x++) + y)"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, program.String())
+		}
+	}
+	l := lexer.New("x--;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	postfix, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.PostfixExpression)
+	if !ok {
+		t.Fatalf("exp is not ast.PostfixExpression. got=%T",
+			program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if postfix.Operator != "--" {
+		t.Errorf("postfix.Operator is not '--'. got=%q", postfix.Operator)
+	}
+	testIdentifier(t, postfix.Left, "x")
+}
+
+func TestInvalidIncrementOperand(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"5++", "1:1: invalid operand for ++: 5 is not assignable"},
+		{"f(x)--", "1:1: invalid operand for --: f(x) is not assignable"},
+		{"skibidi y = ++(a + b);", "1:16: invalid operand for ++: (a + b) is not assignable"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, errs := p.ParseProgramWithErrors()
+		if len(errs) != 1 {
+			t.Fatalf("%q: expected 1 error, got %d: %q", tt.input, len(errs), errs.Messages())
+		}
+		if errs[0].Kind != ErrInvalidOperand {
+			t.Errorf("%q: wrong error kind. got=%s", tt.input, errs[0].Kind)
+		}
+		if errs[0].Error() != tt.message {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.message, errs[0].Error())
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "(x = 5)"},
+		{"a = b = c", "(a = (b = c))"},
+		{"x += y * 2", "(x += (y * 2))"},
+		{"x -= 1", "(x -= 1)"},
+		{"x *= 2 + 3", "(x *= (2 + 3))"},
+		{"x /= 2", "(x /= 2)"},
+		{"x %= 2", "(x %= 2)"},
+		{"x ^= mask", "(x ^= mask)"},
+		{"xs[i] = xs[i] + 1", "((xs[i]) = ((xs[i]) + 1))"},
+		{"a = b || c", "(a = (b || c))"},
+		{"a += b -= 1", "(a += (b -= 1))"},
+		{"f(x = 1)", "f((x = 1))"},
+		{"x += y ** 2", "(x += (y ** 2))"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, program.String())
+		}
+	}
+	l := lexer.New("total += 5;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	assign, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression)
+	if !ok {
+		t.Fatalf("exp is not ast.AssignExpression. got=%T",
+			program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if assign.Operator != "+=" {
+		t.Errorf("assign.Operator is not '+='. got=%q", assign.Operator)
+	}
+	testIdentifier(t, assign.Target, "total")
+	testIntegerLiteral(t, assign.Value, 5)
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"5 = x;", "1:1: cannot assign to 5"},
+		{"f() += 1;", "1:1: cannot assign to f()"},
+		{"a + b = c;", "1:1: cannot assign to (a + b)"},
+		{"skibidi y = -x -= 1;", "1:13: cannot assign to (-x)"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, errs := p.ParseProgramWithErrors()
+		if len(errs) != 1 {
+			t.Fatalf("%q: expected 1 error, got %d: %q", tt.input, len(errs), errs.Messages())
+		}
+		if errs[0].Kind != ErrInvalidAssignment {
+			t.Errorf("%q: wrong error kind. got=%s", tt.input, errs[0].Kind)
+		}
+		if errs[0].Error() != tt.message {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.message, errs[0].Error())
+		}
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello \"world\"\n";`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != "hello \"world\"\n" {
+		t.Errorf("literal.Value not %q. got=%q", "hello \"world\"\n", literal.Value)
+	}
+	if literal.String() != `"hello \"world\"\n"` {
+		t.Errorf("literal.String() wrong. got=%s", literal.String())
+	}
+}
+
+func TestLexicalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"skibidi s = \"abc\nskibidi t = 1;", []string{"1:13: unterminated string literal"}},
+		{`skibidi s = "a\qb";`, []string{`1:15: unknown escape sequence \q`}},
+		{"skibidi x = 1 @ 2;", []string{"1:15: illegal character '@'"}},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, errs := p.ParseProgramWithErrors()
+		if len(errs) != len(tt.expected) {
+			t.Errorf("%q: expected %d errors, got %d: %q", tt.input, len(tt.expected), len(errs), errs.Messages())
+			continue
+		}
+		for i, msg := range tt.expected {
+			if errs[i].Kind != ErrLexical {
+				t.Errorf("%q: wrong error kind. got=%s", tt.input, errs[i].Kind)
+			}
+			if errs[i].Error() != msg {
+				t.Errorf("%q: expected %q, got %q", tt.input, msg, errs[i].Error())
+			}
+		}
+	}
+}
+
+func TestInterpolatedStringExpression(t *testing.T) {
+	input := `"hello ${name}, you have ${count + 1} items"`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+	expectedLiterals := []string{"hello ", ", you have ", " items"}
+	if len(str.Literals) != len(expectedLiterals) {
+		t.Fatalf("len(str.Literals) wrong. expected=%d, got=%d", len(expectedLiterals), len(str.Literals))
+	}
+	for i, literal := range expectedLiterals {
+		if str.Literals[i].Value != literal {
+			t.Errorf("str.Literals[%d] wrong. expected=%q, got=%q", i, literal, str.Literals[i].Value)
+		}
+	}
+	if len(str.Expressions) != 2 {
+		t.Fatalf("len(str.Expressions) wrong. expected=2, got=%d", len(str.Expressions))
+	}
+	testIdentifier(t, str.Expressions[0], "name")
+	testInfixExpression(t, str.Expressions[1], "count", "+", 1)
+	expected := `"hello ${name}, you have ${(count + 1)} items"`
+	if str.String() != expected {
+		t.Errorf("str.String() wrong. expected=%q, got=%q", expected, str.String())
+	}
+}
+
+func TestInterpolatedStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"${a}${b}"`, `"${a}${b}"`},
+		{`"sum: ${f(x, "${y}")}!"`, `"sum: ${f(x, "${y}")}!"`},
+		{`"\${literal} ${x}"`, `"\${literal} ${x}"`},
+		{`"${ {k: 1}[k] }"`, `"${({k: 1}[k])}"`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestInterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a ${x y} b"; skibidi z = 1;`, "1:8: expected } to end interpolated expression, got IDENT instead"},
+		{`"a ${x`, "1:7: expected } to end interpolated expression, got EOF instead"},
+		{`"a ${x} b`, "1:1: unterminated string literal"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, errs := p.ParseProgramWithErrors()
+		if len(errs) == 0 {
+			t.Errorf("%q: expected errors, got none", tt.input)
+			continue
+		}
+		if errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, errs[0].Error())
+		}
+	}
+}
+
+func TestNumericLiteralExpressions(t *testing.T) {
+	integers := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF", 255},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"9223372036854775807", 9223372036854775807},
+	}
+	for _, tt := range integers {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("%q: exp not *ast.IntegerLiteral. got=%T", tt.input, stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("%q: literal.Value not %d. got=%d", tt.input, tt.expected, literal.Value)
+		}
+	}
+	floats := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{"1e-9", 1e-9},
+		{"1_000.5", 1000.5},
+	}
+	for _, tt := range floats {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("%q: exp not *ast.FloatLiteral. got=%T", tt.input, stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("%q: literal.Value not %g. got=%g", tt.input, tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestNumericLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"skibidi x = 9223372036854775808;", "1:13: integer literal 9223372036854775808 overflows int64"},
+		{"skibidi x = 0xFFFFFFFFFFFFFFFFF;", "1:13: integer literal 0xFFFFFFFFFFFFFFFFF overflows int64"},
+		{"skibidi x = -9223372036854775809;", "1:14: integer literal 9223372036854775809 overflows int64"},
+		// ** binds tighter than -, the literal is not negated
+		{"skibidi x = -9223372036854775808 ** 2;", "1:14: integer literal 9223372036854775808 overflows int64"},
+		{"skibidi x = 1e400;", "1:13: float literal 1e400 overflows float64"},
+		{"skibidi x = 0b102;", "1:13: invalid digit '2' in binary literal"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, errs := p.ParseProgramWithErrors()
+		if len(errs) != 1 {
+			t.Errorf("%q: expected 1 error, got %d: %q", tt.input, len(errs), errs.Messages())
+			continue
+		}
+		if errs[0].Error() != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, errs[0].Error())
+		}
+	}
+}
+
+func TestMinInt64Literal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-9223372036854775808", "(-9223372036854775808)"},
+		{"-0x8000000000000000", "(-0x8000000000000000)"},
+		{"-9223372036854775808 + 1", "((-9223372036854775808) + 1)"},
+		{"f(-9_223_372_036_854_775_808)", "f((-9_223_372_036_854_775_808))"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("%q: program.String() wrong. want=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+
+	program := New(lexer.New("-9223372036854775808")).ParseProgram()
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.PrefixExpression)
+	if literal, ok := exp.Right.(*ast.IntegerLiteral); !ok || literal.Value != math.MinInt64 {
+		t.Errorf("wrong operand. want the literal %d, got=%#v", int64(math.MinInt64), exp.Right)
+	}
+}
+
+func TestCommentsAreIgnored(t *testing.T) {
+	input := `// the answer
+skibidi x = 40 /* plus */ + 2; // done`
+	for _, mode := range []lexer.Mode{0, lexer.ScanComments} {
+		l := lexer.New(input)
+		l.SetMode(mode)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != "skibidi x = (40 + 2);" {
+			t.Errorf("program.String() wrong. got=%q", program.String())
+		}
+	}
+}
+
+func TestLogicalExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+	}{
+		{"a && b", "&&"},
+		{"a || b", "||"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.LogicalExpression)
+		if !ok {
+			t.Fatalf("exp is not ast.LogicalExpression. got=%T", stmt.Expression)
+		}
+		testIdentifier(t, exp.Left, "a")
+		if exp.Operator != tt.operator {
+			t.Errorf("exp.Operator is not '%s'. got=%s", tt.operator, exp.Operator)
+		}
+		testIdentifier(t, exp.Right, "b")
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `grind (x < 10) { x += 1; }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("stmt is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+	if !testInfixExpression(t, stmt.Condition, "x", "<", 10) {
+		return
+	}
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("body is not 1 statement. got=%d", len(stmt.Body.Statements))
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"rizz (skibidi i = 0; i < 10; i++) { puts(i); }", "rizz (skibidi i = 0; (i < 10); (i++)) { puts(i) }"},
+		{"rizz (i = 0; i < n; i += 2) {}", "rizz ((i = 0); (i < n); (i += 2)) { }"},
+		{"rizz (;;) { yeet; }", "rizz (; ; ) { yeet; }"},
+		{"rizz (; x;) { mew; }", "rizz (; x; ) { mew; }"},
+		{"rizz x in xs { puts(x); }", "rizz x in xs { puts(x) }"},
+		{"rizz x in [1, 2][0:1] { }", "rizz x in ([1, 2][0:1]) { }"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, program.String())
+		}
+	}
+	l := lexer.New("rizz item in items { total += item; };")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ForInStatement)
+	if !ok {
+		t.Fatalf("stmt is not ast.ForInStatement. got=%T", program.Statements[0])
+	}
+	testIdentifier(t, stmt.Variable, "item")
+	testIdentifier(t, stmt.Iterable, "items")
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"grind (alpha) { if (x) { yeet; } mew; }", nil},
+		{"rizz x in xs { skibidi f = ohio() { rizz (;;) { yeet; } }; mew }", nil},
+		{"yeet;", []string{"1:1: yeet is not in a loop"}},
+		{"skibidi x = 1; mew;", []string{"1:16: mew is not in a loop"}},
+		{"grind (alpha) { ohio() { yeet; }; }", []string{"1:26: yeet is not in a loop"}},
+		{"grind (alpha) { if (x) { if (y) { mew } else if (z) { yeet } } }", nil},
+		{"grind (alpha) { x = if (y) { rizz (;;) { yeet } 1 } else { 2 } }", nil},
+		{"grind (alpha) { x = if (y) { yeet } else { 1 } }", []string{"1:30: yeet cannot leave an if expression whose value is used"}},
+		{"rizz x in xs { puts(if (x) { 1 } else { if (y) { mew } }) }", []string{"1:50: mew cannot leave an if expression whose value is used"}},
+		{"grind (alpha) { if (x) { mew } else { 1 } + 2 }", []string{"1:26: mew cannot leave an if expression whose value is used"}},
+		{"skibidi x = if (y) { yeet } else { 1 }", []string{"1:22: yeet is not in a loop"}},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, errs := p.ParseProgramWithErrors()
+		if len(errs) != len(tt.expected) {
+			t.Errorf("%q: expected %d errors, got %d: %q", tt.input, len(tt.expected), len(errs), errs.Messages())
+			continue
+		}
+		for i, msg := range tt.expected {
+			if errs[i].Kind != ErrMisplacedStatement {
+				t.Errorf("%q: wrong error kind. got=%s", tt.input, errs[i].Kind)
+			}
+			if errs[i].Error() != msg {
+				t.Errorf("%q: expected %q, got %q", tt.input, msg, errs[i].Error())
+			}
+		}
+	}
+}
+
+func TestLoopErrorRecovery(t *testing.T) {
+	input := `rizz (skibidi i = 0; i < 10 { }
+skibidi y = 2;`
+	l := lexer.New(input)
+	p := New(l)
+	program, errs := p.ParseProgramWithErrors()
+	if len(errs) == 0 {
+		t.Fatalf("expected errors, got none")
+	}
+	last := program.Statements[len(program.Statements)-1]
+	testLetStatement(t, last, "y")
+}
+
+func TestConstStatement(t *testing.T) {
+	l := lexer.New("sigma limit = 10;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("s not *ast.LetStatement. got=%T", program.Statements[0])
+	}
+	if !stmt.IsConst() {
+		t.Errorf("stmt.IsConst() is false")
+	}
+	if stmt.Name.Value != "limit" {
+		t.Errorf("stmt.Name.Value not 'limit'. got=%s", stmt.Name.Value)
+	}
+	testIntegerLiteral(t, stmt.Value, 10)
+	if program.String() != "sigma limit = 10;" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestConstAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"sigma x = 1; skibidi y = x + 1; y = 2;", nil},
+		{"sigma x = 1; ohio(x) { x = 2; }", nil},
+		{"sigma x = 1; ohio() { skibidi x = 2; x += 1; }", nil},
+		{"skibidi x = 1; sigma x = 2;", nil},
+		{"sigma xs = [1]; xs[0] = 2;", nil},
+		{"sigma x = 1; x = 2;", []string{"1:14: cannot assign to constant x"}},
+		{"sigma x = 1; x *= 2;", []string{"1:14: cannot assign to constant x"}},
+		{"sigma x = 1; x++;", []string{"1:14: cannot assign to constant x"}},
+		{"sigma x = 1; --x;", []string{"1:16: cannot assign to constant x"}},
+		{"sigma x = 1; ohio() { x -= 1; };", []string{"1:23: cannot assign to constant x"}},
+		{"sigma x = 1; skibidi x = 2;", []string{"1:22: cannot redeclare constant x"}},
+		{"sigma x = 1; rizz x in xs {}", []string{"1:19: cannot redeclare constant x"}},
+		{"sigma x = 1;\nsigma x = 2;\nx = 3;", []string{"2:7: cannot redeclare constant x", "3:1: cannot assign to constant x"}},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, errs := p.ParseProgramWithErrors()
+		if len(errs) != len(tt.expected) {
+			t.Errorf("%q: expected %d errors, got %d: %q", tt.input, len(tt.expected), len(errs), errs.Messages())
+			continue
+		}
+		for i, msg := range tt.expected {
+			if errs[i].Kind != ErrInvalidAssignment {
+				t.Errorf("%q: wrong error kind. got=%s", tt.input, errs[i].Kind)
+			}
+			if errs[i].Error() != msg {
+				t.Errorf("%q: expected %q, got %q", tt.input, msg, errs[i].Error())
+			}
+		}
+	}
+}
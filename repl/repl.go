@@ -0,0 +1,218 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"skibidilang/ast"
+	"skibidilang/evaluator"
+	"skibidilang/lexer"
+	"skibidilang/object"
+	"skibidilang/parser"
+	"skibidilang/token"
+	"strings"
+)
+
+const (
+	Prompt             = ">> "
+	ContinuationPrompt = ".. " // shown while brackets or ${} are left open
+)
+
+const help = `Enter code to evaluate it, bindings are kept until :reset.
+Input with an open (, { or [ or ${} continues on the next line, an empty
+line ends it anyway.
+
+  :ast <code>     print the syntax tree of code
+  :tokens <code>  print the tokens of code
+  :load <file>    evaluate a file
+  :reset          forget all bindings
+  :help           show this help
+  :quit           leave the repl
+`
+
+// session is the state kept between the lines of a repl
+type session struct {
+	out    io.Writer
+	env    *object.Environment
+	exited bool // exit was called, status is what it was passed
+	status int
+}
+
+// Start reads input from in and prints results to out until in ends,
+// :quit is entered or the code calls exit. It returns the status passed to
+// exit, or 0.
+func Start(in io.Reader, out io.Writer) int {
+	scanner := bufio.NewScanner(in)
+	s := &session{out: out, env: object.NewEnvironment()}
+
+	// pending is the input read so far, run is what to do with it once
+	// it is complete
+	var pending string
+	var run func(input string)
+	for !s.exited {
+		if run == nil {
+			fmt.Fprint(out, Prompt)
+		} else {
+			fmt.Fprint(out, ContinuationPrompt)
+		}
+		if !scanner.Scan() {
+			if run != nil {
+				// let the parser report what is missing
+				run(pending)
+			}
+			fmt.Fprintln(out)
+			return s.status
+		}
+		line := scanner.Text()
+
+		if run == nil {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case trimmed == "":
+				continue
+			case strings.HasPrefix(trimmed, ":"):
+				name, arg, _ := strings.Cut(trimmed[1:], " ")
+				arg = strings.TrimSpace(arg)
+				switch name {
+				case "ast":
+					pending, run = arg, s.printAST
+				case "tokens":
+					pending, run = arg, s.printTokens
+				case "load":
+					s.load(arg)
+					continue
+				case "reset":
+					s.env = object.NewEnvironment()
+					continue
+				case "help":
+					fmt.Fprint(out, help)
+					continue
+				case "quit":
+					return s.status
+				default:
+					fmt.Fprintf(out, "unknown command :%s, try :help\n", name)
+					continue
+				}
+			default:
+				pending, run = line, s.eval
+			}
+		} else if strings.TrimSpace(line) != "" {
+			pending += "\n" + line
+		} else {
+			// give up waiting for the closing bracket
+			run(pending)
+			pending, run = "", nil
+			continue
+		}
+
+		if !isIncomplete(pending) {
+			run(pending)
+			pending, run = "", nil
+		}
+	}
+	return s.status
+}
+
+// isIncomplete reports whether input leaves a (, { or [ open, or ends
+// inside a ${} expression of a string literal. Input with more closing than
+// opening brackets, or with a string literal cut off by the end of a line,
+// is complete, it is an error for the parser to report.
+func isIncomplete(input string) bool {
+	l := lexer.New(input)
+	depth := 0
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACK:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACK:
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	if l.UnterminatedString() {
+		// the text of a string cannot continue on the next line
+		return false
+	}
+	return depth > 0 || l.OpenTemplate()
+}
+
+func (s *session) eval(input string) {
+	s.evalProgram(lexer.New(input))
+}
+
+func (s *session) load(filename string) {
+	if filename == "" {
+		fmt.Fprintln(s.out, "usage: :load <file>")
+		return
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	// files may be scripts made executable with a #! line
+	l := lexer.NewFile(filename, string(src))
+	l.SkipShebang()
+	s.evalProgram(l)
+}
+
+func (s *session) evalProgram(l *lexer.Lexer) {
+	program, ok := s.parse(l)
+	if !ok {
+		return
+	}
+	evaluated := evaluator.Eval(program, s.env)
+	switch evaluated := evaluated.(type) {
+	case nil, *object.Null:
+		// statements and calls made for their effect have nothing to show
+	case *object.Error:
+		if evaluated.Exit {
+			s.exited, s.status = true, evaluated.Status
+			return
+		}
+		fmt.Fprintln(s.out, evaluated.Error())
+	default:
+		fmt.Fprintln(s.out, evaluated.Inspect())
+	}
+}
+
+func (s *session) printAST(input string) {
+	program, ok := s.parse(lexer.New(input))
+	if !ok {
+		return
+	}
+	for _, stmt := range program.Statements {
+		fmt.Fprintln(s.out, stmt.String())
+	}
+}
+
+func (s *session) printTokens(input string) {
+	l := lexer.New(input)
+	l.SetMode(lexer.ScanComments)
+	for {
+		tok := l.NextToken()
+		fmt.Fprintf(s.out, "%-8s %-16s %q\n", tok.Pos, tok.Type, tok.Literal)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	for _, err := range l.Errors() {
+		fmt.Fprintln(s.out, err)
+	}
+}
+
+// parse parses a program, printing the errors if there are any
+func (s *session) parse(l *lexer.Lexer) (*ast.Program, bool) {
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		for _, msg := range errors {
+			fmt.Fprintln(s.out, msg)
+		}
+		return nil, false
+	}
+	return program, true
+}
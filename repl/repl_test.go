@@ -0,0 +1,138 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"skibidilang/object"
+	"strings"
+	"testing"
+)
+
+func TestIsIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"", false},
+		{"1 + 2", false},
+		{"skibidi f = ohio(x) {", true},
+		{"skibidi f = ohio(x) {\n  x\n}", false},
+		{"[1, 2,", true},
+		{"puts(", true},
+		{"{\"a\": [1, (2", true},
+		{"1)", false},
+		{"1) + (", false},
+		{`"${x}" + "(["`, false},
+		{"// ( is not code", false},
+		{`puts("abc`, false},
+		{`"abc`, false},
+		{`"a ${x +`, true},
+		{"\"a ${x +\n1} b", false},
+		{"\"a ${x +\n1} b\"", false},
+		{"\"a ${ {\"k\": 1}[\"k\"] }\"", false},
+		{"\"abc\n1", false},
+	}
+	for _, tt := range tests {
+		if got := isIncomplete(tt.input); got != tt.expected {
+			t.Errorf("isIncomplete(%q) = %t, want %t", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	input := `skibidi double = ohio(x) {
+  x * 2
+}
+double(21)
+sigma c = 1;
+
+c = 2
+puts("hi")
+if (beta) { 1 }
+5 / 0
+skibidi y = 1 +;
+[1,
+
+:reset
+double
+#!alpha
+:nope
+`
+	expected := []string{
+		">> .. .. >> 42",
+		">> >> >> 1:1: cannot assign to constant c",
+		">> hi",
+		">> >> 1:1: division by zero",
+		">> 1:16: no prefix parse function for ; found",
+		">> .. 1:4: no prefix parse function for EOF found",
+		">> >> 1:1: identifier not found: double",
+		">> 1:1: illegal character '#'",
+		">> unknown command :nope, try :help",
+		">> ",
+		"",
+	}
+	testSession(t, input, expected)
+}
+
+func TestMetaCommands(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "lib.skb")
+	if err := os.WriteFile(filename, []byte("#!/usr/bin/env skibidi\nskibidi answer = 42;\nanswer + beta"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	input := `:ast 1 + 2 * 3; skibidi x = -a[0]
+:ast ohio(x) {
+x }
+:tokens skibidi x // hi
+:load ` + filename + `
+answer
+:quit
+1
+`
+	expected := []string{
+		">> (1 + (2 * 3))",
+		"skibidi x = (-(a[0]));",
+		">> .. ohio(x) { x }",
+		`>> 1:1      LET              "skibidi"`,
+		`1:9      IDENT            "x"`,
+		`1:11     COMMENT          "// hi"`,
+		`1:16     EOF              ""`,
+		">> " + filename + ":3:1: type mismatch: INTEGER + BOOLEAN",
+		">> 42",
+		">> ",
+	}
+	testSession(t, input, expected)
+}
+
+func TestExit(t *testing.T) {
+	input := `"abc
+exit(300)
+puts("still here")
+exit(7)
+puts("gone")
+`
+	var out bytes.Buffer
+	object.Stdout = &out
+	defer func() { object.Stdout = os.Stdout }()
+	status := Start(strings.NewReader(input), &out)
+	want := ">> 1:1: unterminated string literal\n>> 1:1: exit status must be between 0 and 255, got 300\n>> still here\n>> "
+	if out.String() != want {
+		t.Errorf("wrong output.\nwant=\n%s\ngot=\n%s", want, out.String())
+	}
+	if status != 7 {
+		t.Errorf("wrong exit status. want=7, got=%d", status)
+	}
+}
+
+func testSession(t *testing.T, input string, expected []string) {
+	t.Helper()
+	var out bytes.Buffer
+	object.Stdout = &out
+	defer func() { object.Stdout = os.Stdout }()
+	Start(strings.NewReader(input), &out)
+	want := strings.Join(expected, "\n")
+	if out.String() != want {
+		t.Errorf("wrong output.\nwant=\n%s\ngot=\n%s", want, out.String())
+	}
+}
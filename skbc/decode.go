@@ -0,0 +1,222 @@
+package skbc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"skibidilang/code"
+	"skibidilang/compiler"
+	"skibidilang/object"
+	"skibidilang/token"
+)
+
+// maxNumber bounds the counts, lengths and positions read from a file, so
+// that they fit in an int everywhere
+const maxNumber = math.MaxInt32
+
+// Decode reads a .skbc file. The input is not trusted: besides the
+// checksum, Decode checks that the program cannot make the vm misbehave,
+// see verify.
+func Decode(r io.Reader) (*compiler.Bytecode, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerLen || !bytes.Equal(data[:len(Magic)], []byte(Magic)) {
+		return nil, ErrMagic
+	}
+	if version := binary.BigEndian.Uint16(data[len(Magic):]); version != Version {
+		return nil, fmt.Errorf("%w %d, want %d", ErrVersion, version, Version)
+	}
+	if len(data) < headerLen+checksumLen {
+		return nil, &FormatError{Offset: len(data), Msg: "unexpected end of file"}
+	}
+	end := len(data) - checksumLen
+	if crc32.ChecksumIEEE(data[:end]) != binary.BigEndian.Uint32(data[end:]) {
+		return nil, ErrChecksum
+	}
+
+	d := &decoder{data: data[:end], off: headerLen}
+	numFiles := d.count(1)
+	d.filenames = make([]string, 0, numFiles)
+	for i := 0; i < numFiles && d.err == nil; i++ {
+		d.filenames = append(d.filenames, d.string())
+	}
+	numConstants := d.count(1)
+	if d.err == nil && numConstants > math.MaxUint16+1 {
+		d.fail("too many constants: %d", numConstants)
+	}
+	constants := make([]object.Object, 0, numConstants)
+	for i := 0; i < numConstants && d.err == nil; i++ {
+		constants = append(constants, d.constant())
+	}
+	globalNames := d.strings()
+	ins, lines := d.code()
+	if d.err == nil && d.off != len(d.data) {
+		d.fail("%d bytes of trailing data", len(d.data)-d.off)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	bytecode := &compiler.Bytecode{Instructions: ins, Constants: constants, Lines: lines, GlobalNames: globalNames}
+	if err := verify(bytecode); err != nil {
+		return nil, err
+	}
+	return bytecode, nil
+}
+
+// decoder reads the body of a file. The first problem found is kept in
+// err, after which all reads return zero values.
+type decoder struct {
+	data      []byte
+	off       int
+	filenames []string
+	err       error
+}
+
+func (d *decoder) fail(format string, a ...interface{}) {
+	if d.err == nil {
+		d.err = &FormatError{Offset: d.off, Msg: fmt.Sprintf(format, a...)}
+	}
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if d.off >= len(d.data) {
+		d.fail("unexpected end of file")
+		return 0
+	}
+	b := d.data[d.off]
+	d.off++
+	return b
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.data)-d.off {
+		d.fail("unexpected end of file")
+		return nil
+	}
+	b := d.data[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *decoder) uint64() uint64 {
+	b := d.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// number reads an unsigned varint no larger than maxNumber
+func (d *decoder) number() int {
+	if d.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(d.data[d.off:])
+	if n <= 0 {
+		if n == 0 {
+			d.fail("unexpected end of file")
+		} else {
+			d.fail("varint overflows")
+		}
+		return 0
+	}
+	if x > maxNumber {
+		d.fail("number %d out of range", x)
+		return 0
+	}
+	d.off += n
+	return int(x)
+}
+
+// count reads the number of items that follow, each of which takes at
+// least size bytes. Checking it against the bytes left keeps a corrupt
+// count from allocating huge slices.
+func (d *decoder) count(size int) int {
+	start := d.off
+	n := d.number()
+	if n > (len(d.data)-d.off)/size {
+		d.off = start
+		d.fail("count %d exceeds the size of the file", n)
+		return 0
+	}
+	return n
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(d.number()))
+}
+
+// strings reads a count and that many strings
+func (d *decoder) strings() []string {
+	n := d.count(1)
+	s := make([]string, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		s = append(s, d.string())
+	}
+	return s
+}
+
+func (d *decoder) constant() object.Object {
+	switch tag := d.byte(); tag {
+	case tagInteger:
+		return &object.Integer{Value: int64(d.uint64())}
+	case tagFloat:
+		return &object.Float{Value: math.Float64frombits(d.uint64())}
+	case tagString:
+		return &object.String{Value: d.string()}
+	case tagFunction:
+		fn := &object.CompiledFunction{}
+		fn.NumLocals = d.number()
+		fn.NumParameters = d.number()
+		fn.Name = d.string()
+		fn.LocalNames = d.strings()
+		fn.FreeNames = d.strings()
+		fn.Instructions, fn.Lines = d.code()
+		return fn
+	default:
+		d.off--
+		d.fail("unknown constant tag %d", tag)
+		return nil
+	}
+}
+
+func (d *decoder) code() (code.Instructions, code.LineTable) {
+	ins := code.Instructions(d.bytes(d.number()))
+	numLines := d.count(5)
+	lines := make(code.LineTable, 0, numLines)
+	offset := 0
+	for i := 0; i < numLines && d.err == nil; i++ {
+		start := d.off
+		offset += d.number()
+		file := d.number()
+		pos := token.Position{Offset: d.number(), Line: d.number(), Column: d.number()}
+		if d.err != nil {
+			break
+		}
+		if file >= len(d.filenames) {
+			d.off = start
+			d.fail("file index %d out of range", file)
+			break
+		}
+		if offset >= len(ins) {
+			d.off = start
+			d.fail("line table offset %d outside of the instructions", offset)
+			break
+		}
+		pos.Filename = d.filenames[file]
+		lines = append(lines, code.LineEntry{Offset: offset, Pos: pos})
+	}
+	return ins, lines
+}
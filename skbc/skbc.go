@@ -0,0 +1,163 @@
+package skbc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"skibidilang/code"
+	"skibidilang/compiler"
+	"skibidilang/object"
+)
+
+// A .skbc file holds a compiled program. Integers are big endian, counts
+// and lengths are unsigned varints:
+//
+//	magic      "SKBC"
+//	version    uint16
+//	files      count, then each filename as a string
+//	constants  count, then each constant as a tag byte and its value
+//	globals    count, then the name of each global
+//	main       the instructions and line table of the program
+//	checksum   uint32, CRC-32 (IEEE) of everything before it
+//
+// A string is a length and its bytes. A function constant is its number of
+// locals, number of parameters, name, names of the locals and of the free
+// variables as a count and strings, instructions and line table. A line
+// table is a count, then for each entry the distance to the offset of the
+// previous entry, an index into the files, and the source offset, line and
+// column.
+const (
+	Magic   = "SKBC"
+	Version = 4
+)
+
+const (
+	tagInteger byte = iota + 1
+	tagFloat
+	tagString
+	tagFunction
+)
+
+const headerLen = len(Magic) + 2
+const checksumLen = 4
+
+var (
+	// ErrMagic is returned when decoding something that is not a .skbc file
+	ErrMagic = errors.New("skbc: not a bytecode file")
+	// ErrVersion is returned when decoding a file of another format version
+	ErrVersion = errors.New("skbc: unsupported format version")
+	// ErrChecksum is returned when the contents of a file do not match its
+	// checksum
+	ErrChecksum = errors.New("skbc: checksum mismatch")
+)
+
+// FormatError reports a file that has a valid checksum but does not hold
+// a program the vm can run
+type FormatError struct {
+	Offset int // byte offset in the file, or -1 if the problem is not in one place
+	Msg    string
+}
+
+func (e *FormatError) Error() string {
+	if e.Offset < 0 {
+		return "skbc: malformed file: " + e.Msg
+	}
+	return fmt.Sprintf("skbc: malformed file at byte %d: %s", e.Offset, e.Msg)
+}
+
+// Encode writes bytecode to w in the .skbc format
+func Encode(w io.Writer, bytecode *compiler.Bytecode) error {
+	if len(bytecode.Constants) > math.MaxUint16+1 {
+		return fmt.Errorf("skbc: too many constants: %d", len(bytecode.Constants))
+	}
+	e := &encoder{files: make(map[string]int)}
+	// the file table goes before the code referencing it, so the body is
+	// encoded first
+	var body []byte
+	body = binary.AppendUvarint(body, uint64(len(bytecode.Constants)))
+	for _, constant := range bytecode.Constants {
+		var err error
+		if body, err = e.appendConstant(body, constant); err != nil {
+			return err
+		}
+	}
+	body = appendStrings(body, bytecode.GlobalNames)
+	body = e.appendCode(body, bytecode.Instructions, bytecode.Lines)
+
+	buf := append([]byte(Magic), 0, 0)
+	binary.BigEndian.PutUint16(buf[len(Magic):], Version)
+	buf = binary.AppendUvarint(buf, uint64(len(e.filenames)))
+	for _, filename := range e.filenames {
+		buf = appendString(buf, filename)
+	}
+	buf = append(buf, body...)
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+	_, err := w.Write(buf)
+	return err
+}
+
+type encoder struct {
+	files     map[string]int // index of each filename in filenames
+	filenames []string
+}
+
+func (e *encoder) appendConstant(buf []byte, constant object.Object) ([]byte, error) {
+	switch constant := constant.(type) {
+	case *object.Integer:
+		buf = append(buf, tagInteger)
+		return binary.BigEndian.AppendUint64(buf, uint64(constant.Value)), nil
+	case *object.Float:
+		buf = append(buf, tagFloat)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(constant.Value)), nil
+	case *object.String:
+		buf = append(buf, tagString)
+		return appendString(buf, constant.Value), nil
+	case *object.CompiledFunction:
+		buf = append(buf, tagFunction)
+		buf = binary.AppendUvarint(buf, uint64(constant.NumLocals))
+		buf = binary.AppendUvarint(buf, uint64(constant.NumParameters))
+		buf = appendString(buf, constant.Name)
+		buf = appendStrings(buf, constant.LocalNames)
+		buf = appendStrings(buf, constant.FreeNames)
+		return e.appendCode(buf, constant.Instructions, constant.Lines), nil
+	}
+	return nil, fmt.Errorf("skbc: cannot encode constant of type %s", constant.Type())
+}
+
+func (e *encoder) appendCode(buf []byte, ins code.Instructions, lines code.LineTable) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(ins)))
+	buf = append(buf, ins...)
+	buf = binary.AppendUvarint(buf, uint64(len(lines)))
+	previous := 0
+	for _, entry := range lines {
+		file, ok := e.files[entry.Pos.Filename]
+		if !ok {
+			file = len(e.filenames)
+			e.files[entry.Pos.Filename] = file
+			e.filenames = append(e.filenames, entry.Pos.Filename)
+		}
+		buf = binary.AppendUvarint(buf, uint64(entry.Offset-previous))
+		buf = binary.AppendUvarint(buf, uint64(file))
+		buf = binary.AppendUvarint(buf, uint64(entry.Pos.Offset))
+		buf = binary.AppendUvarint(buf, uint64(entry.Pos.Line))
+		buf = binary.AppendUvarint(buf, uint64(entry.Pos.Column))
+		previous = entry.Offset
+	}
+	return buf
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendStrings(buf []byte, s []string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	for _, str := range s {
+		buf = appendString(buf, str)
+	}
+	return buf
+}
@@ -0,0 +1,340 @@
+package skbc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"skibidilang/code"
+	"skibidilang/compiler"
+	"skibidilang/lexer"
+	"skibidilang/object"
+	"skibidilang/parser"
+	"skibidilang/token"
+	"skibidilang/vm"
+	"testing"
+)
+
+var programs = []string{
+	"",
+	"1 + 2.5",
+	`skibidi name = "héllo"; "${name}!"`,
+	"skibidi fib = ohio(n) { if (n < 2) { goon n; } fib(n - 1) + fib(n - 2) }; fib(10)",
+	"skibidi f = ohio(a) { ohio(b) { a + b } }; f(1)(2)",
+	"skibidi sum = 0; rizz x in [1, 2, 3] { if (x == 2) { mew; } sum += x }; sum",
+	`skibidi h = {"a": 1}; h["b"] = 2; skibidi i = 0; grind (i < 3) { i++; yeet; }; len(h) + i`,
+	"ohio() { skibidi xs = [1, 2]; xs[0]++; xs[0] += 1; alpha && xs[0] == 3 || beta }()",
+	"rizz x in [1, 2] { if (x == 2) { goon x * 10; } }; 3",
+	"skibidi f = ohio(c) { if (c) { skibidi y = 1; }; skibidi g = ohio() { y + z }; skibidi z = 2; g() }; f(alpha)",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, input := range programs {
+		bytecode := compile(t, input)
+		var buf bytes.Buffer
+		if err := Encode(&buf, bytecode); err != nil {
+			t.Fatalf("%q: encode error: %s", input, err)
+		}
+		decoded, err := Decode(&buf)
+		if err != nil {
+			t.Fatalf("%q: decode error: %s", input, err)
+		}
+		if decoded.Instructions.String() != bytecode.Instructions.String() {
+			t.Errorf("%q: wrong instructions.\nwant=\n%sgot=\n%s", input, bytecode.Instructions, decoded.Instructions)
+		}
+		if !equalLines(decoded.Lines, bytecode.Lines) {
+			t.Errorf("%q: wrong line table. want=%v, got=%v", input, bytecode.Lines, decoded.Lines)
+		}
+		if fmt.Sprint(decoded.GlobalNames) != fmt.Sprint(bytecode.GlobalNames) {
+			t.Errorf("%q: wrong global names. want=%q, got=%q", input, bytecode.GlobalNames, decoded.GlobalNames)
+		}
+		if len(decoded.Constants) != len(bytecode.Constants) {
+			t.Fatalf("%q: wrong number of constants. want=%d, got=%d", input, len(bytecode.Constants), len(decoded.Constants))
+		}
+		for i, constant := range bytecode.Constants {
+			if fn, ok := constant.(*object.CompiledFunction); ok {
+				got, ok := decoded.Constants[i].(*object.CompiledFunction)
+				if !ok || got.Instructions.String() != fn.Instructions.String() || got.NumLocals != fn.NumLocals ||
+					got.NumParameters != fn.NumParameters || got.Name != fn.Name || !equalLines(got.Lines, fn.Lines) ||
+					fmt.Sprint(got.LocalNames) != fmt.Sprint(fn.LocalNames) || fmt.Sprint(got.FreeNames) != fmt.Sprint(fn.FreeNames) {
+					t.Errorf("%q: constant %d: wrong function. want=%+v, got=%+v", input, i, fn, decoded.Constants[i])
+				}
+			} else if decoded.Constants[i].Inspect() != constant.Inspect() {
+				t.Errorf("%q: constant %d: want=%s, got=%s", input, i, constant.Inspect(), decoded.Constants[i].Inspect())
+			}
+		}
+
+		want, got := run(t, bytecode), run(t, decoded)
+		if want == nil || got == nil {
+			if want != got {
+				t.Errorf("%q: decoded program gives %v, want %v", input, got, want)
+			}
+		} else if want.Inspect() != got.Inspect() {
+			t.Errorf("%q: decoded program gives %s, want %s", input, got.Inspect(), want.Inspect())
+		}
+	}
+}
+
+func TestDecodeKeepsFilenames(t *testing.T) {
+	bytecode := &compiler.Bytecode{
+		Instructions: concat(code.Make(code.OpTrue), code.Make(code.OpPop)),
+		Lines: code.LineTable{
+			{Offset: 0, Pos: token.Position{Filename: "a.skb", Offset: 4, Line: 1, Column: 5}},
+			{Offset: 1, Pos: token.Position{Filename: "b.skb", Offset: 0, Line: 1, Column: 1}},
+		},
+	}
+	decoded, err := Decode(bytes.NewReader(encode(t, bytecode)))
+	if err != nil {
+		t.Fatalf("decode error: %s", err)
+	}
+	if !equalLines(decoded.Lines, bytecode.Lines) {
+		t.Errorf("wrong line table. want=%v, got=%v", bytecode.Lines, decoded.Lines)
+	}
+}
+
+func TestDecodeRejectsBadHeader(t *testing.T) {
+	valid := encode(t, compile(t, "1 + 2"))
+
+	badVersion := append([]byte{}, valid...)
+	binary.BigEndian.PutUint16(badVersion[len(Magic):], Version+1)
+
+	corrupted := append([]byte{}, valid...)
+	corrupted[headerLen+2] ^= 0xff
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"empty", nil, ErrMagic},
+		{"not bytecode", []byte("skibidi x = 1;"), ErrMagic},
+		{"version", badVersion, ErrVersion},
+		{"checksum", corrupted, ErrChecksum},
+		{"truncated", valid[:len(valid)-1], ErrChecksum},
+	}
+	for _, tt := range tests {
+		_, err := Decode(bytes.NewReader(tt.data))
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: wrong error. want=%v, got=%v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestDecodeRejectsMalformedBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     []byte
+		expected string
+	}{
+		{"empty body", nil, "skbc: malformed file at byte 6: unexpected end of file"},
+		{"huge count", []byte{0, 0xff, 0xff, 0x03}, "skbc: malformed file at byte 7: count 65535 exceeds the size of the file"},
+		{"unknown tag", []byte{0, 1, 9}, "skbc: malformed file at byte 8: unknown constant tag 9"},
+		{"trailing data", []byte{0, 0, 0, 0, 0, 7}, "skbc: malformed file at byte 11: 1 bytes of trailing data"},
+		{"line outside of code", []byte{1, 0, 0, 0, 1, byte(code.OpTrue), 1, 1, 0, 0, 1, 1}, "skbc: malformed file at byte 13: line table offset 1 outside of the instructions"},
+	}
+	for _, tt := range tests {
+		_, err := Decode(bytes.NewReader(withChecksum(tt.body)))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: wrong error. want=%q, got=%v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestDecodeRejectsUnsafeCode(t *testing.T) {
+	function := func(numLocals int, ins ...[]byte) *object.CompiledFunction {
+		return &object.CompiledFunction{Instructions: concat(ins...), NumLocals: numLocals}
+	}
+	tests := []struct {
+		name         string
+		instructions code.Instructions
+		constants    []object.Object
+		expected     string
+	}{
+		{
+			"undefined opcode",
+			code.Instructions{255},
+			nil,
+			"main program: 0000: opcode 255 undefined",
+		},
+		{
+			"truncated instruction",
+			code.Make(code.OpConstant, 0)[:2],
+			nil,
+			"main program: 0000: truncated OpConstant",
+		},
+		{
+			"constant out of range",
+			concat(code.Make(code.OpConstant, 1), code.Make(code.OpPop)),
+			[]object.Object{&object.Integer{Value: 1}},
+			"main program: 0000: constant 1 out of range",
+		},
+		{
+			"stack underflow",
+			concat(code.Make(code.OpTrue), code.Make(code.OpAdd)),
+			nil,
+			"main program: 0001: stack underflow",
+		},
+		{
+			"jump inside instruction",
+			concat(code.Make(code.OpConstant, 0), code.Make(code.OpJump, 1)),
+			[]object.Object{&object.Integer{Value: 1}},
+			"main program: 0003: jump to 0001 inside an instruction",
+		},
+		{
+			"unbalanced branches",
+			concat(
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 5),
+				code.Make(code.OpTrue),
+				code.Make(code.OpPop),
+			),
+			nil,
+			"main program: 0004: stack depth at 0005 is 0 on one path and 1 on another",
+		},
+		{
+			"return from main program",
+			code.Make(code.OpReturn),
+			nil,
+			"main program: 0000: return outside of a function",
+		},
+		{
+			"return value from main program",
+			concat(code.Make(code.OpTrue), code.Make(code.OpReturnValue)),
+			nil,
+			"main program: 0001: return outside of a function",
+		},
+		{
+			"local in main program",
+			concat(code.Make(code.OpGetLocal, 0), code.Make(code.OpPop)),
+			nil,
+			"main program: 0000: local 0 out of range",
+		},
+		{
+			"function without return",
+			code.Make(code.OpClosure, 0, 0),
+			[]object.Object{function(0, code.Make(code.OpNull), code.Make(code.OpPop))},
+			"anonymous function: 0001: function does not return",
+		},
+		{
+			"missing free variables",
+			concat(code.Make(code.OpClosure, 0, 0), code.Make(code.OpPop)),
+			[]object.Object{function(0, code.Make(code.OpGetFree, 1), code.Make(code.OpReturnValue))},
+			"main program: 0000: closure over 0 free variables, want 2",
+		},
+		{
+			"closure over a number",
+			concat(code.Make(code.OpClosure, 0, 0), code.Make(code.OpPop)),
+			[]object.Object{&object.Integer{Value: 1}},
+			"main program: 0000: constant 0 is not a function",
+		},
+		{
+			"too many parameters",
+			nil,
+			[]object.Object{&object.CompiledFunction{Instructions: code.Make(code.OpReturn), NumParameters: 1}},
+			"anonymous function: 0 locals for 1 parameters",
+		},
+	}
+	for _, tt := range tests {
+		bytecode := &compiler.Bytecode{Instructions: tt.instructions, Constants: tt.constants}
+		_, err := Decode(bytes.NewReader(encode(t, bytecode)))
+		var formatErr *FormatError
+		if !errors.As(err, &formatErr) {
+			t.Errorf("%s: expected a FormatError, got=%v", tt.name, err)
+			continue
+		}
+		if formatErr.Msg != tt.expected {
+			t.Errorf("%s: wrong error. want=%q, got=%q", tt.name, tt.expected, formatErr.Msg)
+		}
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, input := range programs {
+		var buf bytes.Buffer
+		if err := Encode(&buf, compile(f, input)); err != nil {
+			f.Fatalf("%q: encode error: %s", input, err)
+		}
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// fix the checksum up, so that mutations reach the decoder
+		if len(data) >= headerLen+checksumLen {
+			end := len(data) - checksumLen
+			binary.BigEndian.PutUint32(data[end:], crc32.ChecksumIEEE(data[:end]))
+		}
+		bytecode, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// whatever was accepted must survive another round trip
+		first := encode(t, bytecode)
+		again, err := Decode(bytes.NewReader(first))
+		if err != nil {
+			t.Fatalf("decoding a re-encoded file: %s", err)
+		}
+		if second := encode(t, again); !bytes.Equal(first, second) {
+			t.Fatalf("encoding is not stable.\nfirst=%x\nsecond=%x", first, second)
+		}
+	})
+}
+
+func compile(t testing.TB, input string) *compiler.Bytecode {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser has errors: %q", input, p.Errors())
+	}
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		t.Fatalf("%q: compiler error: %s", input, err)
+	}
+	return comp.Bytecode()
+}
+
+func run(t *testing.T, bytecode *compiler.Bytecode) object.Object {
+	t.Helper()
+	machine := vm.New(bytecode)
+	if err := machine.Run(); err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	return machine.LastPoppedStackElem()
+}
+
+func encode(t *testing.T, bytecode *compiler.Bytecode) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, bytecode); err != nil {
+		t.Fatalf("encode error: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// withChecksum returns a file made of a valid header, body and a matching
+// checksum
+func withChecksum(body []byte) []byte {
+	data := append([]byte(Magic), 0, Version)
+	data = append(data, body...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+}
+
+func equalLines(a, b code.LineTable) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func concat(ins ...[]byte) code.Instructions {
+	out := code.Instructions{}
+	for _, i := range ins {
+		out = append(out, i...)
+	}
+	return out
+}
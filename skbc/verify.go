@@ -0,0 +1,263 @@
+package skbc
+
+import (
+	"fmt"
+	"math"
+	"skibidilang/code"
+	"skibidilang/compiler"
+	"skibidilang/object"
+)
+
+// instruction is a decoded instruction, next is the offset of the one
+// following it
+type instruction struct {
+	op       code.Opcode
+	operands []int
+	next     int
+}
+
+// verify checks that decoded bytecode is safe to run: every instruction is
+// well formed, refers to constants, locals, builtins and free variables
+// that exist, jumps to the start of an instruction, and finds enough
+// values on the stack. Functions must end with a return on every path.
+func verify(bytecode *compiler.Bytecode) error {
+	main := &object.CompiledFunction{Instructions: bytecode.Instructions}
+	functions := []*object.CompiledFunction{main}
+	for _, constant := range bytecode.Constants {
+		if fn, ok := constant.(*object.CompiledFunction); ok {
+			functions = append(functions, fn)
+		}
+	}
+
+	decoded := make(map[*object.CompiledFunction]map[int]instruction, len(functions))
+	numFree := make(map[*object.CompiledFunction]int, len(functions))
+	for _, fn := range functions {
+		name := functionName(fn, fn == main)
+		if fn.NumLocals > math.MaxUint8 || fn.NumParameters > fn.NumLocals {
+			return &FormatError{Offset: -1, Msg: fmt.Sprintf("%s: %d locals for %d parameters", name, fn.NumLocals, fn.NumParameters)}
+		}
+		instructions, err := decodeInstructions(fn.Instructions)
+		if err != nil {
+			return &FormatError{Offset: -1, Msg: fmt.Sprintf("%s: %s", name, err)}
+		}
+		decoded[fn] = instructions
+		for _, ins := range instructions {
+			isFree := ins.op == code.OpGetFree || ins.op == code.OpSetFree || ins.op == code.OpCaptureFree
+			if isFree && ins.operands[0] >= numFree[fn] {
+				numFree[fn] = ins.operands[0] + 1
+			}
+		}
+	}
+	if numFree[main] > 0 {
+		return &FormatError{Offset: -1, Msg: "main program: free variable outside of a function"}
+	}
+
+	for _, fn := range functions {
+		v := &verifier{
+			constants:    bytecode.Constants,
+			numFree:      numFree,
+			fn:           fn,
+			main:         fn == main,
+			instructions: decoded[fn],
+		}
+		if err := v.verify(); err != nil {
+			return &FormatError{Offset: -1, Msg: fmt.Sprintf("%s: %s", functionName(fn, fn == main), err)}
+		}
+	}
+	return nil
+}
+
+func functionName(fn *object.CompiledFunction, main bool) string {
+	switch {
+	case main:
+		return "main program"
+	case fn.Name != "":
+		return "function " + fn.Name
+	}
+	return "anonymous function"
+}
+
+// decodeInstructions splits ins into instructions, keyed by offset
+func decodeInstructions(ins code.Instructions) (map[int]instruction, error) {
+	instructions := make(map[int]instruction)
+	for offset := 0; offset < len(ins); {
+		def, err := code.Lookup(ins[offset])
+		if err != nil {
+			return nil, fmt.Errorf("%04d: %s", offset, err)
+		}
+		width := 0
+		for _, w := range def.OperandWidths {
+			width += w
+		}
+		if offset+1+width > len(ins) {
+			return nil, fmt.Errorf("%04d: truncated %s", offset, def.Name)
+		}
+		operands, read := code.ReadOperands(def, ins[offset+1:])
+		instructions[offset] = instruction{op: code.Opcode(ins[offset]), operands: operands, next: offset + 1 + read}
+		offset += 1 + read
+	}
+	return instructions, nil
+}
+
+type verifier struct {
+	constants    []object.Object
+	numFree      map[*object.CompiledFunction]int
+	fn           *object.CompiledFunction
+	main         bool
+	instructions map[int]instruction
+	depths       map[int]int // stack depth before each reachable instruction
+	work         []int
+}
+
+func (v *verifier) verify() error {
+	for offset := 0; offset < len(v.fn.Instructions); offset = v.instructions[offset].next {
+		if err := v.checkOperands(v.instructions[offset]); err != nil {
+			return fmt.Errorf("%04d: %s", offset, err)
+		}
+	}
+
+	// follow every path through the code, keeping track of the number of
+	// values the function has on the stack
+	v.depths = make(map[int]int)
+	if err := v.reach(0, 0); err != nil {
+		return err
+	}
+	for len(v.work) > 0 {
+		offset := v.work[len(v.work)-1]
+		v.work = v.work[:len(v.work)-1]
+		if err := v.step(offset); err != nil {
+			return fmt.Errorf("%04d: %s", offset, err)
+		}
+	}
+	return nil
+}
+
+func (v *verifier) checkOperands(ins instruction) error {
+	switch ins.op {
+	case code.OpConstant:
+		if ins.operands[0] >= len(v.constants) {
+			return fmt.Errorf("constant %d out of range", ins.operands[0])
+		}
+	case code.OpClosure:
+		if ins.operands[0] >= len(v.constants) {
+			return fmt.Errorf("constant %d out of range", ins.operands[0])
+		}
+		fn, ok := v.constants[ins.operands[0]].(*object.CompiledFunction)
+		if !ok {
+			return fmt.Errorf("constant %d is not a function", ins.operands[0])
+		}
+		if ins.operands[1] < v.numFree[fn] {
+			return fmt.Errorf("closure over %d free variables, want %d", ins.operands[1], v.numFree[fn])
+		}
+	case code.OpGetLocal, code.OpSetLocal, code.OpCaptureLocal:
+		if ins.operands[0] >= v.fn.NumLocals {
+			return fmt.Errorf("local %d out of range", ins.operands[0])
+		}
+	case code.OpGetBuiltin:
+		if ins.operands[0] >= len(object.Builtins) {
+			return fmt.Errorf("builtin %d out of range", ins.operands[0])
+		}
+	case code.OpHash:
+		if ins.operands[0]%2 != 0 {
+			return fmt.Errorf("odd number of hash elements %d", ins.operands[0])
+		}
+	}
+	return nil
+}
+
+// step checks the instruction at offset and continues with the ones that
+// can run after it
+func (v *verifier) step(offset int) error {
+	ins := v.instructions[offset]
+	depth := v.depths[offset]
+	pops, pushes := stackEffect(ins)
+	if depth < pops {
+		return fmt.Errorf("stack underflow")
+	}
+	after := depth - pops + pushes
+	switch ins.op {
+	case code.OpReturnValue, code.OpReturn:
+		if v.main {
+			// the vm has no caller to return to
+			return fmt.Errorf("return outside of a function")
+		}
+		return nil
+	case code.OpJump:
+		return v.reach(ins.operands[0], after)
+	case code.OpJumpNotTruthy:
+		if err := v.reach(ins.operands[0], after); err != nil {
+			return err
+		}
+	case code.OpIterNext:
+		// the exhausted iterator is popped before jumping
+		if err := v.reach(ins.operands[0], depth-1); err != nil {
+			return err
+		}
+	}
+	return v.reach(ins.next, after)
+}
+
+// reach records that the instruction at offset runs with depth values on
+// the stack, which must be the same on every path leading to it
+func (v *verifier) reach(offset, depth int) error {
+	if offset >= len(v.fn.Instructions) {
+		if offset == len(v.fn.Instructions) && v.main {
+			// the end of the program
+			return nil
+		}
+		if offset == len(v.fn.Instructions) {
+			return fmt.Errorf("function does not return")
+		}
+		return fmt.Errorf("jump to %04d outside of the instructions", offset)
+	}
+	if _, ok := v.instructions[offset]; !ok {
+		return fmt.Errorf("jump to %04d inside an instruction", offset)
+	}
+	if previous, ok := v.depths[offset]; ok {
+		if previous != depth {
+			return fmt.Errorf("stack depth at %04d is %d on one path and %d on another", offset, previous, depth)
+		}
+		return nil
+	}
+	v.depths[offset] = depth
+	v.work = append(v.work, offset)
+	return nil
+}
+
+// stackEffect returns the number of values ins pops off the stack and the
+// number it pushes when it falls through to the next instruction
+func stackEffect(ins instruction) (pops, pushes int) {
+	switch ins.op {
+	case code.OpConstant, code.OpTrue, code.OpFalse, code.OpNull,
+		code.OpGetGlobal, code.OpGetLocal, code.OpGetBuiltin, code.OpGetFree, code.OpCaptureLocal, code.OpCaptureFree:
+		return 0, 1
+	case code.OpPop, code.OpSetGlobal, code.OpSetLocal, code.OpSetFree, code.OpJumpNotTruthy, code.OpReturnValue:
+		return 1, 0
+	case code.OpDup:
+		return ins.operands[0], 2 * ins.operands[0]
+	case code.OpRot:
+		return ins.operands[0] + 1, ins.operands[0] + 1
+	case code.OpMinus, code.OpBang, code.OpBitNot, code.OpIter:
+		return 1, 1
+	case code.OpIterNext:
+		return 1, 2
+	case code.OpIndex:
+		return 2, 1
+	case code.OpSetIndex, code.OpSlice:
+		return 3, 1
+	case code.OpArray, code.OpHash, code.OpConcat:
+		return ins.operands[0], 1
+	case code.OpCall:
+		return ins.operands[0] + 1, 1
+	case code.OpClosure:
+		return ins.operands[1], 1
+	case code.OpJump, code.OpReturn:
+		return 0, 0
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow,
+		code.OpBitAnd, code.OpBitOr, code.OpBitXor, code.OpBitAndNot, code.OpShl, code.OpShr,
+		code.OpEqual, code.OpNotEqual, code.OpLessThan, code.OpLessEqual, code.OpGreaterThan, code.OpGreaterEqual:
+		return 2, 1
+	}
+	// decodeInstructions only accepts defined opcodes
+	panic(fmt.Sprintf("skbc: no stack effect for opcode %d", ins.op))
+}
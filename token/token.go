@@ -1,19 +1,60 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first character of the token
+	End     Position // position immediately after the last character of the token
+}
+
+// Position describes a location in the source. Line and Column are 1-based,
+// Offset is the 0-based byte offset into the input.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// IsValid reports whether the position has been set by the lexer
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position in the form file:line:column, line:column or "-"
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.Filename != "" {
+			return p.Filename
+		}
+		return "-"
+	}
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT"
 
 	//Identifiers + literals
-	IDENT = "IDENT"
-	INT   = "INT"
+	IDENT  = "IDENT"
+	INT    = "INT"
+	FLOAT  = "FLOAT"
+	STRING = "STRING"
+	// Interpolated strings are split into parts around the embedded
+	// expressions: "a ${x} b ${y} c" is TEMPLATE_HEAD("a ") x
+	// TEMPLATE_MIDDLE(" b ") y TEMPLATE_TAIL(" c")
+	TEMPLATE_HEAD   = "TEMPLATE_HEAD"
+	TEMPLATE_MIDDLE = "TEMPLATE_MIDDLE"
+	TEMPLATE_TAIL   = "TEMPLATE_TAIL"
 
 	//operators
 	ASSIGN   = "="
@@ -27,17 +68,32 @@ const (
 	MOD      = "%"
 	LT       = "<"
 	GT       = ">"
-	POWER    = "^"
+	XOR      = "^"
+	BITNOT   = "~"
 	//Two char tokens
-	EQ  = "=="
-	NEQ = "!="
-	INC = "++"
-	DEC = "--"
-	LEQ = "<="
-	GEQ = ">="
+	EQ      = "=="
+	NEQ     = "!="
+	INC     = "++"
+	DEC     = "--"
+	LEQ     = "<="
+	GEQ     = ">="
+	LAND    = "&&"
+	LOR     = "||"
+	POWER   = "**"
+	SHL     = "<<"
+	SHR     = ">>"
+	AND_NOT = "&^"
+	// Compound assignment
+	ADD_ASSIGN = "+="
+	SUB_ASSIGN = "-="
+	MUL_ASSIGN = "*="
+	QUO_ASSIGN = "/="
+	REM_ASSIGN = "%="
+	XOR_ASSIGN = "^="
 	//Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
 
 	LPAREN = "("
 	RPAREN = ")"
@@ -49,21 +105,33 @@ const (
 	//Keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
 )
 
 var keywords = map[string]TokenType{
 	"ohio":    FUNCTION,
 	"skibidi": LET,
+	"sigma":   CONST,
 	"alpha":   TRUE,
 	"beta":    FALSE,
 	"if":      IF,
 	"else":    ELSE,
 	"goon":    RETURN,
+	"grind":   WHILE,
+	"rizz":    FOR,
+	"in":      IN,
+	"yeet":    BREAK,
+	"mew":     CONTINUE,
 }
 
 // LookupIdent return token type based on an identifier string
@@ -74,7 +142,7 @@ func LookupIdent(ident string) TokenType {
 	return IDENT
 }
 
-func NewToken(tokenType TokenType, ch byte) Token {
+func NewToken(tokenType TokenType, ch rune) Token {
 	return Token{Type: tokenType, Literal: string(ch)}
 }
 
@@ -0,0 +1,21 @@
+package vm
+
+import (
+	"skibidilang/code"
+	"skibidilang/object"
+)
+
+// Frame is the state of one function call
+type Frame struct {
+	cl          *object.Closure
+	ip          int // offset of the instruction being executed
+	basePointer int // stack index of the first local
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
@@ -0,0 +1,37 @@
+package vm
+
+import (
+	"fmt"
+	"skibidilang/object"
+)
+
+// iterator is the state of a rizz-in loop. It only ever lives on the
+// stack, between OpIter and the OpIterNext that exhausts it.
+type iterator struct {
+	elements []object.Object
+	next     int
+}
+
+func (it *iterator) Type() object.ObjectType { return "ITERATOR" }
+func (it *iterator) Inspect() string         { return "iterator" }
+
+// newIterator snapshots the elements of an array, the characters of a
+// string or the keys of a hash
+func newIterator(iterable object.Object) (*iterator, error) {
+	var elements []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		elements = append(elements, iterable.Elements...)
+	case *object.String:
+		for _, r := range iterable.Value {
+			elements = append(elements, &object.String{Value: string(r)})
+		}
+	case *object.Hash:
+		for _, key := range iterable.Keys {
+			elements = append(elements, iterable.Pairs[key].Key)
+		}
+	default:
+		return nil, fmt.Errorf("cannot iterate over %s", iterable.Type())
+	}
+	return &iterator{elements: elements}, nil
+}
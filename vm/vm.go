@@ -0,0 +1,768 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"skibidilang/code"
+	"skibidilang/compiler"
+	"skibidilang/object"
+	"strings"
+)
+
+const (
+	StackSize   = 2048
+	GlobalsSize = 65536
+	MaxFrames   = 1024
+)
+
+var (
+	True  = &object.Boolean{Value: true}
+	False = &object.Boolean{Value: false}
+	Null  = &object.Null{}
+)
+
+var operatorNames = map[code.Opcode]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpMod:          "%",
+	code.OpPow:          "**",
+	code.OpBitAnd:       "&",
+	code.OpBitOr:        "|",
+	code.OpBitXor:       "^",
+	code.OpBitAndNot:    "&^",
+	code.OpShl:          "<<",
+	code.OpShr:          ">>",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpLessThan:     "<",
+	code.OpLessEqual:    "<=",
+	code.OpGreaterThan:  ">",
+	code.OpGreaterEqual: ">=",
+}
+
+type VM struct {
+	constants []object.Object
+
+	stack []object.Object
+	sp    int // always points to the next free slot, the top of the stack is stack[sp-1]
+
+	globals     []object.Object
+	globalNames []string
+
+	frames      []*Frame
+	framesIndex int
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions, Lines: bytecode.Lines}
+	mainClosure := &object.Closure{Fn: mainFn}
+	mainFrame := NewFrame(mainClosure, 0)
+
+	frames := make([]*Frame, MaxFrames)
+	frames[0] = mainFrame
+
+	return &VM{
+		constants:   bytecode.Constants,
+		stack:       make([]object.Object, StackSize),
+		sp:          0,
+		globals:     make([]object.Object, GlobalsSize),
+		globalNames: bytecode.GlobalNames,
+		frames:      frames,
+		framesIndex: 1,
+	}
+}
+
+// NewWithGlobalsStore returns a VM sharing globals with an earlier one,
+// to run programs compiled one after the other
+func NewWithGlobalsStore(bytecode *compiler.Bytecode, s []object.Object) *VM {
+	vm := New(bytecode)
+	vm.globals = s
+	return vm
+}
+
+// LastPoppedStackElem returns the value of the last expression statement
+// executed, or the value returned by a top-level goon
+func (vm *VM) LastPoppedStackElem() object.Object {
+	return vm.stack[vm.sp]
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) {
+	vm.frames[vm.framesIndex] = f
+	vm.framesIndex++
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+// Run executes the program. A runtime error is returned as an
+// *object.Error positioned at the instruction that failed.
+func (vm *VM) Run() error {
+	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		vm.currentFrame().ip++
+		if err := vm.step(); err != nil {
+			frame := vm.currentFrame()
+			pos := frame.cl.Fn.Lines.Lookup(frame.ip)
+			if builtinErr, ok := err.(*object.Error); ok {
+				// keep the exit status of exit
+				builtinErr.Pos = pos
+				return builtinErr
+			}
+			return &object.Error{Message: err.Error(), Pos: pos}
+		}
+		if vm.framesIndex == 0 {
+			// the main program returned, compiled code jumps to its end
+			// instead
+			return nil
+		}
+	}
+	return nil
+}
+
+// step executes the instruction at the instruction pointer of the
+// current frame
+func (vm *VM) step() error {
+	frame := vm.currentFrame()
+	ip := frame.ip
+	ins := frame.Instructions()
+	op := code.Opcode(ins[ip])
+
+	switch op {
+	case code.OpConstant:
+		constIndex := code.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		return vm.push(vm.constants[constIndex])
+
+	case code.OpPop:
+		vm.pop()
+
+	case code.OpDup:
+		n := int(code.ReadUint8(ins[ip+1:]))
+		frame.ip += 1
+		base := vm.sp - n
+		for i := 0; i < n; i++ {
+			if err := vm.push(vm.stack[base+i]); err != nil {
+				return err
+			}
+		}
+
+	case code.OpRot:
+		n := int(code.ReadUint8(ins[ip+1:]))
+		frame.ip += 1
+		top := vm.stack[vm.sp-1]
+		copy(vm.stack[vm.sp-n:vm.sp], vm.stack[vm.sp-n-1:vm.sp-1])
+		vm.stack[vm.sp-n-1] = top
+
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow,
+		code.OpBitAnd, code.OpBitOr, code.OpBitXor, code.OpBitAndNot, code.OpShl, code.OpShr,
+		code.OpEqual, code.OpNotEqual, code.OpLessThan, code.OpLessEqual, code.OpGreaterThan, code.OpGreaterEqual:
+		right := vm.pop()
+		left := vm.pop()
+		result, err := executeBinaryOperation(op, left, right)
+		if err != nil {
+			return err
+		}
+		return vm.push(result)
+
+	case code.OpTrue:
+		return vm.push(True)
+	case code.OpFalse:
+		return vm.push(False)
+	case code.OpNull:
+		return vm.push(Null)
+
+	case code.OpBang:
+		return vm.push(nativeBoolToBooleanObject(!isTruthy(vm.pop())))
+
+	case code.OpMinus:
+		switch operand := vm.pop().(type) {
+		case *object.Integer:
+			return vm.push(&object.Integer{Value: -operand.Value})
+		case *object.Float:
+			return vm.push(&object.Float{Value: -operand.Value})
+		default:
+			return fmt.Errorf("unknown operator: -%s", operand.Type())
+		}
+
+	case code.OpBitNot:
+		operand := vm.pop()
+		integer, ok := operand.(*object.Integer)
+		if !ok {
+			return fmt.Errorf("unknown operator: ~%s", operand.Type())
+		}
+		return vm.push(&object.Integer{Value: ^integer.Value})
+
+	case code.OpJump:
+		pos := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip = pos - 1
+
+	case code.OpJumpNotTruthy:
+		pos := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		if !isTruthy(vm.pop()) {
+			frame.ip = pos - 1
+		}
+
+	case code.OpSetGlobal:
+		globalIndex := code.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		vm.globals[globalIndex] = vm.pop()
+
+	case code.OpGetGlobal:
+		globalIndex := code.ReadUint16(ins[ip+1:])
+		frame.ip += 2
+		global := vm.globals[globalIndex]
+		if global == nil {
+			return unset(vm.globalNames, int(globalIndex))
+		}
+		return vm.push(global)
+
+	case code.OpSetLocal:
+		localIndex := code.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		local := &vm.stack[frame.basePointer+int(localIndex)]
+		// a captured local lives in a cell shared with closures
+		if cell, ok := (*local).(*object.Cell); ok {
+			cell.Value = vm.pop()
+		} else {
+			*local = vm.pop()
+		}
+
+	case code.OpGetLocal:
+		localIndex := code.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		local := vm.stack[frame.basePointer+int(localIndex)]
+		if cell, ok := local.(*object.Cell); ok {
+			local = cell.Value
+		}
+		if local == nil {
+			return unset(frame.cl.Fn.LocalNames, int(localIndex))
+		}
+		return vm.push(local)
+
+	case code.OpGetBuiltin:
+		builtinIndex := code.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		return vm.push(object.Builtins[builtinIndex])
+
+	case code.OpGetFree:
+		freeIndex := code.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		free := frame.cl.Free[freeIndex].Value
+		if free == nil {
+			return unset(frame.cl.Fn.FreeNames, int(freeIndex))
+		}
+		return vm.push(free)
+
+	case code.OpSetFree:
+		freeIndex := code.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		frame.cl.Free[freeIndex].Value = vm.pop()
+
+	case code.OpCaptureLocal:
+		localIndex := code.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		local := &vm.stack[frame.basePointer+int(localIndex)]
+		cell, ok := (*local).(*object.Cell)
+		if !ok {
+			// from now on the local is read and written through the cell
+			cell = &object.Cell{Value: *local}
+			*local = cell
+		}
+		return vm.push(cell)
+
+	case code.OpCaptureFree:
+		freeIndex := code.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		return vm.push(frame.cl.Free[freeIndex])
+
+	case code.OpArray:
+		numElements := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		elements := make([]object.Object, numElements)
+		copy(elements, vm.stack[vm.sp-numElements:vm.sp])
+		vm.sp = vm.sp - numElements
+		return vm.push(&object.Array{Elements: elements})
+
+	case code.OpHash:
+		numElements := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		hash, err := vm.buildHash(vm.sp-numElements, vm.sp)
+		if err != nil {
+			return err
+		}
+		vm.sp = vm.sp - numElements
+		return vm.push(hash)
+
+	case code.OpIndex:
+		index := vm.pop()
+		left := vm.pop()
+		result, err := executeIndexExpression(left, index)
+		if err != nil {
+			return err
+		}
+		return vm.push(result)
+
+	case code.OpSetIndex:
+		val := vm.pop()
+		index := vm.pop()
+		left := vm.pop()
+		if err := executeSetIndex(left, index, val); err != nil {
+			return err
+		}
+		return vm.push(val)
+
+	case code.OpSlice:
+		high := vm.pop()
+		low := vm.pop()
+		left := vm.pop()
+		result, err := executeSliceExpression(left, low, high)
+		if err != nil {
+			return err
+		}
+		return vm.push(result)
+
+	case code.OpConcat:
+		numParts := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		var out strings.Builder
+		for _, part := range vm.stack[vm.sp-numParts : vm.sp] {
+			out.WriteString(part.Inspect())
+		}
+		vm.sp = vm.sp - numParts
+		return vm.push(&object.String{Value: out.String()})
+
+	case code.OpIter:
+		iter, err := newIterator(vm.pop())
+		if err != nil {
+			return err
+		}
+		return vm.push(iter)
+
+	case code.OpIterNext:
+		pos := int(code.ReadUint16(ins[ip+1:]))
+		frame.ip += 2
+		iter, ok := vm.stack[vm.sp-1].(*iterator)
+		if !ok {
+			return fmt.Errorf("cannot iterate over %s", vm.stack[vm.sp-1].Type())
+		}
+		if iter.next < len(iter.elements) {
+			iter.next++
+			return vm.push(iter.elements[iter.next-1])
+		}
+		vm.pop()
+		frame.ip = pos - 1
+
+	case code.OpCall:
+		numArgs := code.ReadUint8(ins[ip+1:])
+		frame.ip += 1
+		return vm.executeCall(int(numArgs))
+
+	case code.OpReturnValue:
+		returnValue := vm.pop()
+		frame := vm.popFrame()
+		if vm.framesIndex == 0 {
+			// leave the value where LastPoppedStackElem finds it
+			return nil
+		}
+		vm.sp = frame.basePointer - 1
+		return vm.push(returnValue)
+
+	case code.OpReturn:
+		frame := vm.popFrame()
+		if vm.framesIndex == 0 {
+			return nil
+		}
+		vm.sp = frame.basePointer - 1
+		return vm.push(Null)
+
+	case code.OpClosure:
+		constIndex := code.ReadUint16(ins[ip+1:])
+		numFree := code.ReadUint8(ins[ip+3:])
+		frame.ip += 3
+		return vm.pushClosure(int(constIndex), int(numFree))
+
+	default:
+		return fmt.Errorf("unknown opcode %d", op)
+	}
+	return nil
+}
+
+func (vm *VM) push(o object.Object) error {
+	if vm.sp >= StackSize {
+		return fmt.Errorf("stack overflow")
+	}
+	vm.stack[vm.sp] = o
+	vm.sp++
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
+
+func (vm *VM) executeCall(numArgs int) error {
+	callee := vm.stack[vm.sp-1-numArgs]
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, numArgs)
+	case *object.Builtin:
+		return vm.callBuiltin(callee, numArgs)
+	}
+	return fmt.Errorf("not a function: %s", callee.Type())
+}
+
+func (vm *VM) callClosure(cl *object.Closure, numArgs int) error {
+	if numArgs != cl.Fn.NumParameters {
+		return fmt.Errorf("wrong number of arguments: want=%d, got=%d", cl.Fn.NumParameters, numArgs)
+	}
+	if vm.framesIndex >= MaxFrames {
+		return fmt.Errorf("stack overflow")
+	}
+	basePointer := vm.sp - numArgs
+	if basePointer+cl.Fn.NumLocals >= StackSize {
+		return fmt.Errorf("stack overflow")
+	}
+	// locals that are not parameters start out unset
+	for i := vm.sp; i < basePointer+cl.Fn.NumLocals; i++ {
+		vm.stack[i] = nil
+	}
+	vm.pushFrame(NewFrame(cl, basePointer))
+	vm.sp = basePointer + cl.Fn.NumLocals
+	return nil
+}
+
+func (vm *VM) callBuiltin(builtin *object.Builtin, numArgs int) error {
+	args := vm.stack[vm.sp-numArgs : vm.sp]
+	result := builtin.Fn(args...)
+	vm.sp = vm.sp - numArgs - 1
+	if err, ok := result.(*object.Error); ok {
+		return err
+	}
+	return vm.push(orNull(result))
+}
+
+func (vm *VM) pushClosure(constIndex, numFree int) error {
+	function, ok := vm.constants[constIndex].(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %+v", vm.constants[constIndex])
+	}
+	free := make([]*object.Cell, numFree)
+	for i, captured := range vm.stack[vm.sp-numFree : vm.sp] {
+		cell, ok := captured.(*object.Cell)
+		if !ok {
+			return fmt.Errorf("closure over a value that is not a captured variable")
+		}
+		free[i] = cell
+	}
+	vm.sp = vm.sp - numFree
+	return vm.push(&object.Closure{Fn: function, Free: free})
+}
+
+func (vm *VM) buildHash(startIndex, endIndex int) (object.Object, error) {
+	hash := object.NewHash()
+	for i := startIndex; i < endIndex; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i+1]
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash, nil
+}
+
+func executeBinaryOperation(op code.Opcode, left, right object.Object) (object.Object, error) {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return executeIntegerOperation(op, left.(*object.Integer).Value, right.(*object.Integer).Value)
+	case isNumber(left) && isNumber(right):
+		return executeFloatOperation(op, toFloat(left), toFloat(right))
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return executeStringOperation(op, left.(*object.String).Value, right.(*object.String).Value)
+	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
+		l, r := left.(*object.Boolean).Value, right.(*object.Boolean).Value
+		switch op {
+		case code.OpEqual:
+			return nativeBoolToBooleanObject(l == r), nil
+		case code.OpNotEqual:
+			return nativeBoolToBooleanObject(l != r), nil
+		}
+	case op == code.OpEqual:
+		return nativeBoolToBooleanObject(left == right), nil
+	case op == code.OpNotEqual:
+		return nativeBoolToBooleanObject(left != right), nil
+	case left.Type() != right.Type():
+		return nil, fmt.Errorf("type mismatch: %s %s %s", left.Type(), operatorNames[op], right.Type())
+	}
+	return nil, fmt.Errorf("unknown operator: %s %s %s", left.Type(), operatorNames[op], right.Type())
+}
+
+func executeIntegerOperation(op code.Opcode, l, r int64) (object.Object, error) {
+	switch op {
+	case code.OpAdd:
+		return &object.Integer{Value: l + r}, nil
+	case code.OpSub:
+		return &object.Integer{Value: l - r}, nil
+	case code.OpMul:
+		return &object.Integer{Value: l * r}, nil
+	case code.OpDiv, code.OpMod:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if op == code.OpDiv {
+			return &object.Integer{Value: l / r}, nil
+		}
+		return &object.Integer{Value: l % r}, nil
+	case code.OpPow:
+		if r < 0 {
+			return nil, fmt.Errorf("negative exponent %d for integer power", r)
+		}
+		return &object.Integer{Value: intPow(l, r)}, nil
+	case code.OpBitAnd:
+		return &object.Integer{Value: l & r}, nil
+	case code.OpBitOr:
+		return &object.Integer{Value: l | r}, nil
+	case code.OpBitXor:
+		return &object.Integer{Value: l ^ r}, nil
+	case code.OpBitAndNot:
+		return &object.Integer{Value: l &^ r}, nil
+	case code.OpShl, code.OpShr:
+		if r < 0 {
+			return nil, fmt.Errorf("negative shift count %d", r)
+		}
+		if op == code.OpShl {
+			return &object.Integer{Value: l << uint64(r)}, nil
+		}
+		return &object.Integer{Value: l >> uint64(r)}, nil
+	case code.OpEqual:
+		return nativeBoolToBooleanObject(l == r), nil
+	case code.OpNotEqual:
+		return nativeBoolToBooleanObject(l != r), nil
+	case code.OpLessThan:
+		return nativeBoolToBooleanObject(l < r), nil
+	case code.OpLessEqual:
+		return nativeBoolToBooleanObject(l <= r), nil
+	case code.OpGreaterThan:
+		return nativeBoolToBooleanObject(l > r), nil
+	case code.OpGreaterEqual:
+		return nativeBoolToBooleanObject(l >= r), nil
+	}
+	return nil, fmt.Errorf("unknown operator: INTEGER %s INTEGER", operatorNames[op])
+}
+
+// intPow computes base**exp by repeated squaring, wrapping around on
+// overflow like the other integer operators
+func intPow(base, exp int64) int64 {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exp >>= 1
+	}
+	return result
+}
+
+func executeFloatOperation(op code.Opcode, l, r float64) (object.Object, error) {
+	switch op {
+	case code.OpAdd:
+		return &object.Float{Value: l + r}, nil
+	case code.OpSub:
+		return &object.Float{Value: l - r}, nil
+	case code.OpMul:
+		return &object.Float{Value: l * r}, nil
+	case code.OpDiv:
+		return &object.Float{Value: l / r}, nil
+	case code.OpMod:
+		return &object.Float{Value: math.Mod(l, r)}, nil
+	case code.OpPow:
+		return &object.Float{Value: math.Pow(l, r)}, nil
+	case code.OpEqual:
+		return nativeBoolToBooleanObject(l == r), nil
+	case code.OpNotEqual:
+		return nativeBoolToBooleanObject(l != r), nil
+	case code.OpLessThan:
+		return nativeBoolToBooleanObject(l < r), nil
+	case code.OpLessEqual:
+		return nativeBoolToBooleanObject(l <= r), nil
+	case code.OpGreaterThan:
+		return nativeBoolToBooleanObject(l > r), nil
+	case code.OpGreaterEqual:
+		return nativeBoolToBooleanObject(l >= r), nil
+	}
+	return nil, fmt.Errorf("unknown operator: FLOAT %s FLOAT", operatorNames[op])
+}
+
+func executeStringOperation(op code.Opcode, l, r string) (object.Object, error) {
+	switch op {
+	case code.OpAdd:
+		return &object.String{Value: l + r}, nil
+	case code.OpEqual:
+		return nativeBoolToBooleanObject(l == r), nil
+	case code.OpNotEqual:
+		return nativeBoolToBooleanObject(l != r), nil
+	case code.OpLessThan:
+		return nativeBoolToBooleanObject(l < r), nil
+	case code.OpLessEqual:
+		return nativeBoolToBooleanObject(l <= r), nil
+	case code.OpGreaterThan:
+		return nativeBoolToBooleanObject(l > r), nil
+	case code.OpGreaterEqual:
+		return nativeBoolToBooleanObject(l >= r), nil
+	}
+	return nil, fmt.Errorf("unknown operator: STRING %s STRING", operatorNames[op])
+}
+
+func executeIndexExpression(left, index object.Object) (object.Object, error) {
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := checkIndex(index, len(left.Elements))
+		if err != nil {
+			return nil, err
+		}
+		return left.Elements[i], nil
+	case *object.String:
+		runes := []rune(left.Value)
+		i, err := checkIndex(index, len(runes))
+		if err != nil {
+			return nil, err
+		}
+		return &object.String{Value: string(runes[i])}, nil
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", index.Type())
+		}
+		if val, ok := left.Get(key); ok {
+			return val, nil
+		}
+		return Null, nil
+	}
+	return nil, fmt.Errorf("index operator not supported: %s", left.Type())
+}
+
+func executeSetIndex(left, index, val object.Object) error {
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := checkIndex(index, len(left.Elements))
+		if err != nil {
+			return err
+		}
+		left.Elements[i] = val
+		return nil
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return fmt.Errorf("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, val)
+		return nil
+	}
+	return fmt.Errorf("index assignment not supported: %s", left.Type())
+}
+
+func checkIndex(index object.Object, length int) (int, error) {
+	i, ok := index.(*object.Integer)
+	if !ok {
+		return 0, fmt.Errorf("index must be INTEGER, got %s", index.Type())
+	}
+	if i.Value < 0 || i.Value >= int64(length) {
+		return 0, fmt.Errorf("index out of range [%d] with length %d", i.Value, length)
+	}
+	return int(i.Value), nil
+}
+
+// executeSliceExpression slices an array or a string, a null bound stands
+// for a bound left out in the source
+func executeSliceExpression(left, lowBound, highBound object.Object) (object.Object, error) {
+	var length int
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elements)
+	case *object.String:
+		length = len([]rune(left.Value))
+	default:
+		return nil, fmt.Errorf("slice operator not supported: %s", left.Type())
+	}
+	low, high := int64(0), int64(length)
+	for _, bound := range []struct {
+		obj   object.Object
+		value *int64
+	}{{lowBound, &low}, {highBound, &high}} {
+		if bound.obj == Null {
+			continue
+		}
+		i, ok := bound.obj.(*object.Integer)
+		if !ok {
+			return nil, fmt.Errorf("slice index must be INTEGER, got %s", bound.obj.Type())
+		}
+		*bound.value = i.Value
+	}
+	if low < 0 || high < low || high > int64(length) {
+		return nil, fmt.Errorf("slice bounds out of range [%d:%d] with length %d", low, high, length)
+	}
+	if array, ok := left.(*object.Array); ok {
+		elements := make([]object.Object, high-low)
+		copy(elements, array.Elements[low:high])
+		return &object.Array{Elements: elements}, nil
+	}
+	return &object.String{Value: string([]rune(left.(*object.String).Value)[low:high])}, nil
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return True
+	}
+	return False
+}
+
+// isTruthy reports whether obj counts as true in a condition, only beta and
+// null count as false
+func isTruthy(obj object.Object) bool {
+	switch obj := obj.(type) {
+	case *object.Boolean:
+		return obj.Value
+	case *object.Null:
+		return false
+	}
+	return true
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+// unset reports reading a variable before it was assigned, which the
+// evaluator sees as a name that is not defined yet
+func unset(names []string, index int) error {
+	if index < len(names) && names[index] != "" {
+		return fmt.Errorf("identifier not found: %s", names[index])
+	}
+	return fmt.Errorf("identifier not found")
+}
+
+func orNull(obj object.Object) object.Object {
+	if obj == nil {
+		return Null
+	}
+	return obj
+}
@@ -0,0 +1,419 @@
+package vm
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"skibidilang/ast"
+	"skibidilang/code"
+	"skibidilang/compiler"
+	"skibidilang/evaluator"
+	"skibidilang/lexer"
+	"skibidilang/object"
+	"skibidilang/parser"
+	"testing"
+)
+
+type vmTestCase struct {
+	input    string
+	expected interface{}
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"5", 5},
+		{"-10", -10},
+		{"-9223372036854775808", math.MinInt64},
+		{"-9223372036854775808 + 1", math.MinInt64 + 1},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 % 3", 1},
+		{"2 ** 3 ** 2", 512},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"6 &^ 3", 4},
+		{"1 << 4", 16},
+		{"-16 >> 2", -4},
+		{"~5", -6},
+		{"1.5 + 1", 2.5},
+		{"1 / 4.0", 0.25},
+		{"7.5 % 2", 1.5},
+	}
+	runVmTests(t, tests)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"alpha", true},
+		{"1 < 2", true},
+		{"1 >= 2", false},
+		{"1 == 1.0", true},
+		{"alpha != beta", true},
+		{"(1 < 2) == alpha", true},
+		{`"a" < "b"`, true},
+		{`"a" == 1`, false},
+		{"!5", false},
+		{"!!beta", false},
+		{"!(if (beta) { 5 })", true},
+		{"1 && 0", true},
+		{"beta || 0", true},
+		{"beta || beta", false},
+		{"skibidi x = 0; x != 0 && 10 / x > 2", false},
+		{"skibidi x = 0; x == 0 || 10 / x > 2", true},
+	}
+	runVmTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []vmTestCase{
+		{"if (alpha) { 10 }", 10},
+		{"if (beta) { 10 }", Null},
+		{"if (1) { 10 }", 10},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 > 2) { 10 } else if (2 > 1) { 30 } else { 20 }", 30},
+		{"if (alpha) { skibidi a = 1; }", Null},
+	}
+	runVmTests(t, tests)
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []vmTestCase{
+		{"goon 10;", 10},
+		{"9; goon 2 * 5; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { goon 10; } goon 1; }", 10},
+		{"skibidi f = ohio(x) { goon x; x + 10; }; f(10);", 10},
+		{"skibidi f = ohio() { goon; 5 }; f()", Null},
+		{"skibidi f = ohio() { }; f()", Null},
+		{"rizz x in [1, 2, 3] { if (x == 2) { goon x; } }; 5", 2},
+	}
+	runVmTests(t, tests)
+
+	// compiled code jumps to the end of the main program instead of
+	// returning from it, other bytecode must not crash the vm
+	for _, ins := range []code.Instructions{
+		code.Make(code.OpReturn),
+		append(code.Make(code.OpTrue), code.Make(code.OpReturnValue)...),
+	} {
+		if err := New(&compiler.Bytecode{Instructions: ins}).Run(); err != nil {
+			t.Errorf("%s: vm error: %s", ins, err)
+		}
+	}
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []vmTestCase{
+		{"skibidi a = 1; a = 2; a", 2},
+		{"skibidi a = 1; skibidi b = 1; a = b = 5; a + b", 10},
+		{"skibidi a = 10; a += 5; a -= 3; a *= 2; a /= 4; a %= 4; a", 2},
+		{"skibidi a = 6; a ^= 3", 5},
+		{"skibidi a = 1; a++; a++; a--; a", 2},
+		{"skibidi a = 1; a++", 1},
+		{"skibidi a = 1; ++a", 2},
+		{"skibidi xs = [1, 2, 3]; xs[1] = 5; xs[1] += 1; xs[1]", 6},
+		{"skibidi xs = [1, 2, 3]; xs[0]++", 1},
+		{"skibidi xs = [1, 2, 3]; xs[0]++; xs[0]--; xs[0]++ + xs[0]", 3},
+		{"skibidi xs = [0.1]; xs[0]++", 0.1},
+		{"skibidi xs = [0.1]; xs[0]--; xs[0]", 0.1 - 1},
+		{"skibidi xs = [1, 2, 3]; --xs[0]; xs[0]", 0},
+		{`skibidi h = {"a": 1}; h["b"] = 2; h["a"] += 10; h["a"] + h["b"]`, 13},
+		{"skibidi n = 0; skibidi inc = ohio() { n += 1 }; inc(); inc(); n", 2},
+		{"skibidi n = 0; skibidi f = ohio(n) { n = 5 }; f(1); n", 0},
+		{"sigma a = 5; ohio() { skibidi a = 1; a += 1 }() + a", 7},
+		// the target is read before the right-hand side changes it
+		{"skibidi x = 1; x += x++; x", 2},
+		{"skibidi xs = [1]; xs[0] *= xs[0]++ + 1; xs[0]", 2},
+	}
+	runVmTests(t, tests)
+}
+
+func TestLoops(t *testing.T) {
+	tests := []vmTestCase{
+		{"skibidi i = 0; grind (i < 10) { i++ }; i", 10},
+		{"skibidi sum = 0; rizz (skibidi i = 1; i <= 10; i++) { sum += i }; sum", 55},
+		{"skibidi sum = 0; rizz (skibidi i = 0; i < 10; i++) { if (i % 2 == 0) { mew; } sum += i }; sum", 25},
+		{"skibidi i = 0; rizz (;;) { if (i == 7) { yeet; } i++ }; i", 7},
+		{"skibidi sum = 0; rizz x in [1, 2, 3] { sum += x }; sum", 6},
+		{`skibidi n = 0; rizz c in "héllo" { n++ }; n`, 5},
+		{`skibidi h = {"a": 1, "b": 2}; skibidi sum = 0; rizz k in h { sum += h[k] }; sum`, 3},
+		{"skibidi sum = 0; rizz x in [1, 2, 3, 4] { if (x == 3) { yeet; } sum += x }; sum", 3},
+		{"skibidi f = ohio() { rizz x in [1, 2, 3] { if (x == 2) { goon x * 10; } } 0 }; f()", 20},
+		{"skibidi n = 0; rizz (skibidi i = 0; i < 3; i++) { rizz (skibidi j = 0; j < 3; j++) { if (j == 1) { yeet; } n++ } }; n", 3},
+		{"skibidi n = 0; rizz x in [1, 2, 3, 4, 5] { if (x > 1) { if (x == 2) { mew } else if (x == 4) { yeet } } n += x }; n", 4},
+		{"skibidi n = 0; rizz x in [1, 2, 3] { n += if (x > 1) { rizz (;;) { yeet } x } else { 10 } }; n", 15},
+		{"ohio() { skibidi sum = 0; rizz x in [1, 2] { rizz y in [10, 20] { sum += x * y } }; sum }()", 90},
+	}
+	runVmTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{"skibidi identity = ohio(x) { x; }; identity(5);", 5},
+		{"skibidi add = ohio(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"ohio(x) { x; }(5)", 5},
+		{"skibidi f = ohio() { skibidi a = 1; skibidi b = 2; a + b }; f() + f()", 6},
+		{"skibidi newAdder = ohio(x) { ohio(y) { x + y } }; skibidi addTwo = newAdder(2); addTwo(3);", 5},
+		{"skibidi f = ohio(a) { ohio(b) { ohio(c) { a + b + c } } }; f(1)(2)(3)", 6},
+		{"skibidi fib = ohio(n) { if (n < 2) { goon n; } fib(n - 1) + fib(n - 2) }; fib(15)", 610},
+		{`skibidi wrapper = ohio() {
+			skibidi countDown = ohio(x) { if (x == 0) { goon 0; } countDown(x - 1) };
+			countDown(1);
+		};
+		wrapper();`, 0},
+	}
+	runVmTests(t, tests)
+}
+
+func TestStringsArraysAndHashes(t *testing.T) {
+	tests := []vmTestCase{
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`skibidi name = "skibidi"; skibidi n = 2; "hi ${name}, ${n + 1} items"`, "hi skibidi, 3 items"},
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[1:3]`, "él"},
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{"skibidi xs = [1, 2, 3, 4]; xs[1:3]", "[2, 3]"},
+		{"skibidi xs = [1, 2, 3, 4]; xs[:2]", "[1, 2]"},
+		{"skibidi xs = [1, 2, 3, 4]; xs[2:]", "[3, 4]"},
+		{`{"one": 1, 2: "two", alpha: 3}`, `{"one": 1, 2: "two", alpha: 3}`},
+		{`{"a": 1}["b"]`, "null"},
+		{`len("héllo")`, "5"},
+		{`push([1], 2)`, "[1, 2]"},
+		{`rest([1, 2, 3])`, "[2, 3]"},
+		{`rest([])`, "null"},
+	}
+	for _, tt := range tests {
+		result, err := run(t, tt.input)
+		if err != nil {
+			t.Errorf("%q: vm error: %s", tt.input, err)
+			continue
+		}
+		var got string
+		if str, ok := result.(*object.String); ok {
+			got = str.Value
+		} else {
+			got = result.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + alpha;", "1:1: type mismatch: INTEGER + BOOLEAN"},
+		{"-alpha", "1:1: unknown operator: -BOOLEAN"},
+		{"5; alpha + beta; 5", "1:4: unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) {\n  goon alpha + beta;\n}", "2:8: unknown operator: BOOLEAN + BOOLEAN"},
+		{`"Hello" - "World"`, "1:1: unknown operator: STRING - STRING"},
+		{"10 / 0", "1:1: division by zero"},
+		{"2 ** -1", "1:1: negative exponent -1 for integer power"},
+		{"1 << -1", "1:1: negative shift count -1"},
+		{`{[1]: 2}`, "1:1: unusable as hash key: ARRAY"},
+		{"[1, 2][2]", "1:1: index out of range [2] with length 2"},
+		{"[1, 2, 3][2:1]", "1:1: slice bounds out of range [2:1] with length 3"},
+		{"5(1)", "1:1: not a function: INTEGER"},
+		{"ohio(x) { x }()", "1:1: wrong number of arguments: want=1, got=0"},
+		{`len(1)`, "1:1: argument to `len` not supported, got INTEGER"},
+		{"rizz x in 5 {}", "1:11: cannot iterate over INTEGER"},
+		{`skibidi s = "abc"; s[0] = "b"`, "1:20: index assignment not supported: STRING"},
+		{"skibidi f = ohio(x) {\n  x / 0\n}; f(1)", "2:3: division by zero"},
+		{"skibidi f = ohio() { f() }; f()", "1:22: stack overflow"},
+	}
+	for _, tt := range tests {
+		_, err := run(t, tt.input)
+		if err == nil {
+			t.Errorf("%q: expected a runtime error", tt.input)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expected, err.Error())
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	input := "skibidi f = ohio(x) {\n  rizz i in [1, 2] { exit(x + i) }\n}; f(3); 1"
+	_, err := run(t, input)
+	exit, ok := err.(*object.Error)
+	if !ok || !exit.Exit || exit.Status != 4 {
+		t.Fatalf("expected exit with status 4, got=%#v", err)
+	}
+	if exit.Pos.String() != "2:22" {
+		t.Errorf("wrong position. want=2:22, got=%s", exit.Pos)
+	}
+}
+
+func TestPuts(t *testing.T) {
+	var out bytes.Buffer
+	object.Stdout = &out
+	defer func() { object.Stdout = os.Stdout }()
+	runVmTests(t, []vmTestCase{{`puts("hello", 42, [1, "a"])`, Null}})
+	expected := "hello\n42\n[1, \"a\"]\n"
+	if out.String() != expected {
+		t.Errorf("wrong output. expected=%q, got=%q", expected, out.String())
+	}
+}
+
+func TestGlobalsStore(t *testing.T) {
+	globals := make([]object.Object, GlobalsSize)
+	symbolTable := compiler.NewSymbolTable()
+	for i, builtin := range object.Builtins {
+		symbolTable.DefineBuiltin(i, builtin.Name)
+	}
+	var constants []object.Object
+	var result object.Object
+	for _, input := range []string{"skibidi a = 40;", "skibidi f = ohio() { a + 2 };", "f()"} {
+		comp := compiler.NewWithState(symbolTable, constants)
+		if err := comp.Compile(parse(t, input)); err != nil {
+			t.Fatalf("%q: compiler error: %s", input, err)
+		}
+		bytecode := comp.Bytecode()
+		constants = bytecode.Constants
+		machine := NewWithGlobalsStore(bytecode, globals)
+		if err := machine.Run(); err != nil {
+			t.Fatalf("%q: vm error: %s", input, err)
+		}
+		result = machine.LastPoppedStackElem()
+	}
+	testExpectedObject(t, "f()", 42, result)
+}
+
+// TestSameAsEvaluator runs programs on the vm and on the evaluator, which
+// must agree on the result
+func TestSameAsEvaluator(t *testing.T) {
+	inputs := []string{
+		// closures share the variables they capture with the function
+		// declaring them
+		"skibidi f = ohio() { skibidi x = 1; skibidi g = ohio() { x }; x = 2; g() }; f()",
+		"skibidi f = ohio() { skibidi x = 1; skibidi g = ohio() { x = 5 }; g(); x }; f()",
+		"skibidi counter = ohio() { skibidi n = 0; ohio() { n++ } }; skibidi c = counter(); c(); c(); c()",
+		"skibidi adder = ohio(n) { ohio() { n += 1 } }; skibidi a = adder(1); a(); a()",
+		"skibidi f = ohio(a) { skibidi g = ohio() { ohio() { a += 10 } }; g()(); a }; f(1)",
+		"skibidi pair = ohio() { skibidi v = 0; [ohio() { v++ }, ohio() { v }] }; skibidi p = pair(); p[0](); p[0](); p[1]()",
+		"ohio() { skibidi fs = []; rizz x in [1, 2, 3] { fs = push(fs, ohio() { x }) }; fs[0]() + fs[2]() }()",
+		"ohio() { skibidi x = 1; skibidi g = ohio() { x }; skibidi x = 3; g() }()",
+		"ohio() { skibidi fact = ohio(n) { if (n < 2) { goon 1; } n * fact(n - 1) }; fact(5) }()",
+		"skibidi f = ohio() { f }; skibidi g = f; f = 5; g()",
+		"skibidi a = [0.1]; a[0]++",
+		"skibidi sum = 0; rizz x in [1, 2, 3] { sum += if (x > 1) { rizz (;;) { yeet } x } else { 10 } }; sum",
+		"skibidi f = ohio(n) { f(n + 1) }; f(0)",
+		"skibidi f = ohio() { skibidi x = 1; ohio() { x + alpha } }; f()()",
+		"skibidi x = 1; x += x++; x",
+		"ohio() { skibidi x = 1; skibidi g = ohio() { x = 10 }; x += g(); x }()",
+		// a variable is only there once its declaration ran, code before
+		// it may refer to it
+		"ohio(c) { if (c) { skibidi y = 1; }; y }(beta)",
+		"ohio(c) { if (c) { skibidi y = 1; }; y }(alpha)",
+		"if (beta) { skibidi y = 1; }; y",
+		"skibidi f = ohio() { g }; f(); skibidi g = 1",
+		"ohio() { x; skibidi x = 1 }()",
+		"ohio() { skibidi g = ohio() { x }; g(); skibidi x = 3 }()",
+		"skibidi f = ohio() { g() }; skibidi g = ohio() { 2 }; f()",
+		"ohio() { skibidi g = ohio() { x }; skibidi x = 3; g() }()",
+		"ohio() { skibidi even = ohio(n) { if (n == 0) { alpha } else { odd(n - 1) } }; skibidi odd = ohio(n) { if (n == 0) { beta } else { even(n - 1) } }; even(10) }()",
+		"rizz i in [1, 2] { if (i == 2) { goon seen } skibidi seen = i }",
+	}
+	for _, input := range inputs {
+		program := parse(t, input)
+		want := evaluator.Eval(program, object.NewEnvironment())
+
+		comp := compiler.New()
+		if err := comp.Compile(program); err != nil {
+			t.Errorf("%q: compiler error: %s", input, err)
+			continue
+		}
+		machine := New(comp.Bytecode())
+		if err := machine.Run(); err != nil {
+			// positions are not compared, the vm can run out of stack
+			// space somewhere else than the evaluator runs out of calls
+			errObj, ok := want.(*object.Error)
+			if !ok || errObj.Message != err.(*object.Error).Message {
+				t.Errorf("%q: vm error %q, evaluator gives %s", input, err, want.Inspect())
+			}
+			continue
+		}
+		if got := machine.LastPoppedStackElem(); got.Inspect() != want.Inspect() {
+			t.Errorf("%q: vm gives %s, evaluator gives %s", input, got.Inspect(), want.Inspect())
+		}
+	}
+}
+
+func BenchmarkFib30(b *testing.B) {
+	input := `skibidi fib = ohio(n) { if (n < 2) { goon n; } fib(n - 1) + fib(n - 2) }; fib(30)`
+	program := parser.New(lexer.New(input)).ParseProgram()
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		b.Fatalf("compiler error: %s", err)
+	}
+	bytecode := comp.Bytecode()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := New(bytecode).Run(); err != nil {
+			b.Fatalf("vm error: %s", err)
+		}
+	}
+}
+
+func runVmTests(t *testing.T, tests []vmTestCase) {
+	t.Helper()
+	for _, tt := range tests {
+		result, err := run(t, tt.input)
+		if err != nil {
+			t.Errorf("%q: vm error: %s", tt.input, err)
+			continue
+		}
+		testExpectedObject(t, tt.input, tt.expected, result)
+	}
+}
+
+func run(t *testing.T, input string) (object.Object, error) {
+	t.Helper()
+	comp := compiler.New()
+	if err := comp.Compile(parse(t, input)); err != nil {
+		t.Fatalf("%q: compiler error: %s", input, err)
+	}
+	vm := New(comp.Bytecode())
+	if err := vm.Run(); err != nil {
+		return nil, err
+	}
+	return vm.LastPoppedStackElem(), nil
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser has errors: %q", input, p.Errors())
+	}
+	return program
+}
+
+func testExpectedObject(t *testing.T, input string, expected interface{}, actual object.Object) {
+	t.Helper()
+	switch expected := expected.(type) {
+	case int:
+		result, ok := actual.(*object.Integer)
+		if !ok || result.Value != int64(expected) {
+			t.Errorf("%q: expected %d, got=%T (%+v)", input, expected, actual, actual)
+		}
+	case float64:
+		result, ok := actual.(*object.Float)
+		if !ok || result.Value != expected {
+			t.Errorf("%q: expected %g, got=%T (%+v)", input, expected, actual, actual)
+		}
+	case bool:
+		result, ok := actual.(*object.Boolean)
+		if !ok || result.Value != expected {
+			t.Errorf("%q: expected %t, got=%T (%+v)", input, expected, actual, actual)
+		}
+	case *object.Null:
+		if actual != Null {
+			t.Errorf("%q: object is not Null. got=%T (%+v)", input, actual, actual)
+		}
+	}
+}